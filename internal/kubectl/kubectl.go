@@ -72,9 +72,10 @@ func (k *Kubectl) KubectlDeleteString(str string, options ...string) error {
 
 // KubectlDrain runs kubectl drain in k.Directory, on a specified node with flags --ignore-daemonsets --delete-emptydir-data
 // example: kubectl drain node1 -> k.KubectlDrain("node1")
-func (k *Kubectl) KubectlDrain(nodeName string) error {
+// example: kubectl drain node1 --disable-eviction -> k.KubectlDrain("node1", "--disable-eviction")
+func (k *Kubectl) KubectlDrain(nodeName string, options ...string) error {
 	command := fmt.Sprintf("kubectl drain %s --ignore-daemonsets --delete-emptydir-data %s", nodeName, k.getKubeconfig())
-	return k.run(command)
+	return k.run(command, options...)
 }
 
 // KubectlDescribe runs kubectl describe in k.Directory, on a specified resource, resource name and specified namespace
@@ -192,6 +192,7 @@ type Cluster struct {
 	// The current kubeone version used in Claudie is 1.5.
 	// To see the list of supported versions, please refer to kubeone documentation.
 	// https://docs.kubermatic.com/kubeone/v1.5/architecture/compatibility/supported-versions/#supported-kubernetes-versions
+	// The patch version may be set to x, i.e. v1.26.x, to use the latest patch release of the minor version supported by Claudie.
 	Version string `validate:"required,ver" yaml:"version" json:"version"`
 	// Network range for the VPN of the cluster. The value should be defined in format A.B.C.D/mask.
 	Network string `validate:"required,cidrv4" yaml:"network" json:"network"`
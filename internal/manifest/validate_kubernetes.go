@@ -14,15 +14,31 @@ var (
 	// NOTE:
 	// first/second capturing group MUST be changed whenever new kubeone version is introduced in Claudie
 	// so validation will catch unsupported versions
-	semverRegexString = `^(1)\.(24|25|26)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`
+	// The patch version may be "x" to use the latest patch release of the minor version, see latestPatchVersions.
+	semverRegexString = `^(1)\.(24|25|26)\.(0|[1-9]\d*|x)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`
 
 	// semverRegex is a regex using the semverRegexString.
 	// It's used to verify the version inside the manifest,
 	// as kubernetes follows the semantic version terminology
 	// https://kubernetes.io/releases/
 	semverRegex = regexp.MustCompile(semverRegexString)
+
+	// latestPatchVersions are the latest patch releases of the minor versions supported by kubeone 1.6.2,
+	// which is the kubeone version currently used in Claudie. A version with the "x" patch is resolved to them.
+	// NOTE:
+	// MUST be changed together with the semverRegexString whenever new kubeone version is introduced in Claudie.
+	// The table is pinned rather than looked up, so that the resolved version does not change between builds,
+	// thus whoever bumps the kubeone version in the kube-eleven Dockerfile bumps the patch releases here as well.
+	latestPatchVersions = map[string]string{
+		"1.24": "1.24.12",
+		"1.25": "1.25.8",
+		"1.26": "1.26.3",
+	}
 )
 
+// latestPatch is the patch version which resolves to the latest patch release of the minor version.
+const latestPatch = "x"
+
 // Validate validates the parsed data inside the Kubernetes section of the manifest.
 // It checks for missing/invalid filled out values defined in the Kubernetes section
 // of the manifest.
@@ -85,5 +101,30 @@ func validateVersion(fl validator.FieldLevel) bool {
 	// drop the 'v' as it's not part of a semantic version (https://semver.org/)
 	semverString = strings.TrimPrefix(semverString, "v")
 
-	return semverRegex.MatchString(semverString)
+	match := semverRegex.FindStringSubmatch(semverString)
+	if match == nil {
+		return false
+	}
+
+	// the "x" patch resolves to a release, thus it can not be combined with a pre-release or build metadata.
+	return match[3] != latestPatch || (match[4] == "" && match[5] == "")
+}
+
+// KubernetesVersion returns the kubernetes version of the cluster with the "x" patch, i.e. v1.26.x,
+// resolved to the latest patch release in latestPatchVersions, preserving the "v" prefix.
+// Other versions are returned as is.
+func (c *Cluster) KubernetesVersion() string {
+	minor, ok := strings.CutSuffix(strings.TrimPrefix(c.Version, "v"), "."+latestPatch)
+	if !ok {
+		return c.Version
+	}
+
+	resolved, ok := latestPatchVersions[minor]
+	if !ok {
+		return c.Version
+	}
+	if strings.HasPrefix(c.Version, "v") {
+		return "v" + resolved
+	}
+	return resolved
 }
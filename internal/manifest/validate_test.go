@@ -137,3 +137,40 @@ func TestNodepools(t *testing.T) {
 	err := testK8s.Validate()
 	require.NoError(t, err)
 }
+
+// TestKubernetesVersion tests the resolution of the kubernetes version with the "x" patch.
+func TestKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		Name    string
+		version string
+		want    string
+	}{
+		{Name: "latest-patch", version: "1.26.x", want: "1.26.3"},
+		{Name: "latest-patch-v-prefix", version: "v1.24.x", want: "v1.24.12"},
+		{Name: "concrete-version", version: "v1.25.2", want: "v1.25.2"},
+		{Name: "unsupported-minor", version: "v1.27.x", want: "v1.27.x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			c := &Cluster{Version: tt.version}
+			require.Equal(t, tt.want, c.KubernetesVersion())
+		})
+	}
+}
+
+// TestKubernetesVersionLatestPatch tests that each of the supported minor versions resolves to a version passing the validation.
+func TestKubernetesVersionLatestPatch(t *testing.T) {
+	for minor, patch := range latestPatchVersions {
+		c := Cluster{Name: "cluster1", Network: "10.0.0.0/8", Version: "v" + minor + ".x", Pools: Pool{Control: []string{"np1"}}}
+		require.NoError(t, c.Validate())
+
+		c.Version = c.KubernetesVersion()
+		require.Equal(t, "v"+patch, c.Version)
+		require.NoError(t, c.Validate())
+
+		c.Version = "v" + minor + ".x-rc.0"
+		require.Error(t, c.Validate())
+		c.Version = "v" + minor + ".x+build.1"
+		require.Error(t, c.Validate())
+	}
+}
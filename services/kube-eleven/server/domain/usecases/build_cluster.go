@@ -18,6 +18,9 @@ func (u *Usecases) BuildCluster(req *pb.BuildClusterRequest) (*pb.BuildClusterRe
 		K8sCluster:        req.Desired,
 		LBClusters:        req.DesiredLbs,
 		SpawnProcessLimit: u.SpawnProcessLimit,
+		ProjectName:       req.ProjectName,
+		// The manifest allows clusters without compute nodepools, these are built with the control plane only.
+		AllowNoWorkers: !kube_eleven.ExpectsWorkers(req.Desired),
 	}
 
 	if err := k.BuildCluster(); err != nil {
@@ -20,6 +20,7 @@ func (u *Usecases) DestroyCluster(req *pb.DestroyClusterRequest) (*pb.DestroyClu
 		K8sCluster:        req.Current,
 		LBClusters:        req.CurrentLbs,
 		SpawnProcessLimit: u.SpawnProcessLimit,
+		ProjectName:       req.ProjectName,
 	}
 
 	if err := k.DestroyCluster(); err != nil {
@@ -0,0 +1,27 @@
+package kube_eleven
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/berops/claudie/internal/kubectl"
+)
+
+// controlPlaneBootstrapped checks, using the kubeconfig of the k.K8sCluster, whether the control
+// plane of the cluster is already up and healthy. The check is conservative, any error or
+// inconclusive response is treated as not bootstrapped.
+func (k *KubeEleven) controlPlaneBootstrapped() bool {
+	if k.K8sCluster.GetKubeconfig() == "" {
+		return false
+	}
+
+	kc := kubectl.Kubectl{Kubeconfig: k.K8sCluster.GetKubeconfig(), MaxKubectlRetries: 1}
+	out, err := kc.KubectlGet("--raw=/readyz")
+	if err != nil {
+		k.logEvent(zerolog.DebugLevel, stageBootstrap).Err(err).Msg("Control plane is not reachable, treating the cluster as not bootstrapped")
+		return false
+	}
+
+	return strings.TrimSpace(string(out)) == "ok"
+}
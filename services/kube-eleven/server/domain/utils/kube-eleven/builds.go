@@ -0,0 +1,213 @@
+package kube_eleven
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berops/claudie/internal/utils"
+)
+
+const (
+	// ownerFileName is the marker file within the output directory holding the replica which owns the build.
+	ownerFileName = ".owner"
+	// identityFileName is the marker file within the output directory holding the cluster instance the directory belongs to.
+	identityFileName = ".identity"
+	// identityTokenLength is the number of hex characters of the identity hash appended to the output directory name.
+	identityTokenLength = 8
+)
+
+// replicaID identifies this replica of kube-eleven as the owner of the builds,
+// defaults to the hostname, which is the pod name when running in kubernetes.
+var replicaID = utils.GetEnvDefault("KUBE_ELEVEN_REPLICA_ID", hostname())
+
+// BuildInfo describes a cluster build which has its output directory present on disk.
+type BuildInfo struct {
+	// ClusterID is the ID of the cluster, read from the identity marker, or
+	// the name of the output directory if the marker is not present.
+	ClusterID string
+	// Directory is the output directory of the build.
+	Directory string
+	// Metadata read from the header of the generated kubeone manifest.
+	// Nil if the manifest is not present or does not contain the header.
+	Metadata *BuildMetadata
+	// Owner is the replica which owns the build. Empty if the owner marker is not present.
+	Owner string
+}
+
+// ListActiveBuilds lists the output directories of the builds found under the baseDir.
+// Directories removed while listing, i.e. by a build which just finished, are skipped.
+func ListActiveBuilds(baseDir string) ([]BuildInfo, error) {
+	dir := filepath.Join(baseDir, outputDirectory)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var builds []BuildInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		info := BuildInfo{
+			ClusterID: entry.Name(),
+			Directory: filepath.Join(dir, entry.Name()),
+		}
+
+		identity, err := readIdentity(info.Directory)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity of %s: %w", info.ClusterID, err)
+		}
+		if identity != "" {
+			_, info.ClusterID, _ = strings.Cut(identity, "/")
+		}
+
+		metadata, err := readBuildMetadata(filepath.Join(info.Directory, generatedKubeoneManifestName))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read build metadata for %s: %w", info.ClusterID, err)
+		}
+		info.Metadata = metadata
+
+		owner, err := os.ReadFile(filepath.Join(info.Directory, ownerFileName))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read owner of %s: %w", info.ClusterID, err)
+		}
+		info.Owner = strings.TrimSpace(string(owner))
+
+		builds = append(builds, info)
+	}
+
+	return builds, nil
+}
+
+// CleanupOrphanedBuilds finds the output directories left behind by the builds of this replica, i.e. after
+// a crash, and removes them if remove is set. Must be called before the replica starts building, as all of
+// its builds present on disk are considered orphaned. Builds owned by other replicas are left untouched.
+// Returns the orphaned builds.
+func CleanupOrphanedBuilds(remove bool) ([]BuildInfo, error) {
+	builds, err := ListActiveBuilds(baseDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		orphaned []BuildInfo
+		errs     []error
+	)
+	for _, build := range builds {
+		if build.Owner != replicaID {
+			continue
+		}
+		orphaned = append(orphaned, build)
+		if remove {
+			if err := removeAll(build.Directory); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove %s: %w", build.Directory, err))
+			}
+		}
+	}
+
+	return orphaned, errors.Join(errs...)
+}
+
+// writeOwner marks the build in the directory as owned by this replica.
+func writeOwner(dir string) error {
+	return os.WriteFile(filepath.Join(dir, ownerFileName), []byte(replicaID), 0600)
+}
+
+// clusterIdentity returns the identity of the cluster instance, i.e. the project along with the cluster ID.
+func (k *KubeEleven) clusterIdentity() string {
+	return fmt.Sprintf("%s/%s", k.ProjectName, utils.GetClusterID(k.K8sCluster.ClusterInfo))
+}
+
+// identityToken returns the short token derived from the identity, which is appended to the output directory name.
+func identityToken(identity string) string {
+	sum := sha256.Sum256([]byte(identity))
+	return hex.EncodeToString(sum[:])[:identityTokenLength]
+}
+
+// writeIdentity marks the directory as belonging to the cluster instance with the identity.
+func writeIdentity(dir, identity string) error {
+	return os.WriteFile(filepath.Join(dir, identityFileName), []byte(identity), 0600)
+}
+
+// readIdentity returns the identity of the cluster instance the directory belongs to.
+// Returns an empty string if the directory or its identity marker does not exist.
+func readIdentity(dir string) (string, error) {
+	identity, err := os.ReadFile(filepath.Join(dir, identityFileName))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(identity)), nil
+}
+
+// hostname returns the hostname, or an empty string if it can not be determined.
+func hostname() string {
+	h, _ := os.Hostname()
+	return h
+}
+
+// readBuildMetadata parses the metadata header of the kubeone manifest at the path.
+// Returns nil if the manifest has no header.
+func readBuildMetadata(path string) (*BuildMetadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		m     BuildMetadata
+		found bool
+	)
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+
+		key, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "#")), ": ")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "claudie-version":
+			m.ClaudieVersion = value
+		case "build-timestamp":
+			m.BuildTimestamp = value
+		case "cluster-hash":
+			m.ClusterHash = value
+		case "spec-hash":
+			m.SpecHash = value
+		default:
+			continue
+		}
+		found = true
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return &m, nil
+}
@@ -0,0 +1,66 @@
+package kube_eleven
+
+import (
+	"context"
+	"fmt"
+)
+
+// BuildPhase is the phase of a build.
+type BuildPhase string
+
+// Phases of a build, in the order of their occurrence.
+const (
+	BuildPhaseGenerateFiles BuildPhase = "GenerateFiles"
+	BuildPhaseApply         BuildPhase = "Apply"
+	BuildPhasePostApply     BuildPhase = "PostApply"
+)
+
+type (
+	// buildProgress tracks how far the current build got.
+	buildProgress struct {
+		phase             BuildPhase
+		filesGenerated    bool
+		kubeconfigFetched bool
+	}
+
+	// BuildCancelledError is returned by BuildClusterContext if the context was cancelled during the build.
+	// It describes the progress of the cancelled build, i.e. to decide whether resuming the build is viable.
+	BuildCancelledError struct {
+		// Phase is the last phase the build has reached.
+		Phase BuildPhase
+		// FilesGenerated is set if the files for kubeone were generated.
+		FilesGenerated bool
+		// KubeconfigFetched is set if the kubeconfig of the cluster was fetched after kubeone apply.
+		KubeconfigFetched bool
+		// Err is the error the build failed with.
+		Err error
+	}
+)
+
+func (e *BuildCancelledError) Error() string {
+	return fmt.Sprintf("build cancelled in phase %s (files generated: %t, kubeconfig fetched: %t): %v",
+		e.Phase, e.FilesGenerated, e.KubeconfigFetched, e.Err)
+}
+
+func (e *BuildCancelledError) Unwrap() error { return e.Err }
+
+// enterPhase records the phase reached by the current build.
+func (k *KubeEleven) enterPhase(phase BuildPhase) { k.progress.phase = phase }
+
+// cancelled returns the error of the ctx, wrapped with the phase of the build, if it is done.
+func (k *KubeEleven) cancelled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("build of %s cancelled in phase %s: %w", k.K8sCluster.ClusterInfo.Name, k.progress.phase, err)
+	}
+	return nil
+}
+
+// cancelledError returns the BuildCancelledError for the err, based on the progress of the build.
+func (k *KubeEleven) cancelledError(err error) *BuildCancelledError {
+	return &BuildCancelledError{
+		Phase:             k.progress.phase,
+		FilesGenerated:    k.progress.filesGenerated,
+		KubeconfigFetched: k.progress.kubeconfigFetched,
+		Err:               err,
+	}
+}
@@ -0,0 +1,68 @@
+package kube_eleven
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// cleanupGracePeriod is the time for which the removal of the output directory is retried.
+	cleanupGracePeriod = 2 * time.Second
+	// cleanupRetryDelay is the delay between the attempts to remove the output directory.
+	cleanupRetryDelay = 200 * time.Millisecond
+)
+
+// removeSecretFiles removes the files holding secrets from the output directory, i.e. the SSH keys, the kubeconfig
+// and the kubeone manifest, leaving the remaining generated files, i.e. the owner and identity files, for inspection
+// of a failed build.
+func (k *KubeEleven) removeSecretFiles() error {
+	files, err := filepath.Glob(filepath.Join(k.outputDirectory, "*.pem"))
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{
+		fmt.Sprintf("%s-kubeconfig", k.K8sCluster.GetClusterInfo().GetName()),
+		generatedKubeoneManifestName,
+	} {
+		files = append(files, filepath.Join(k.outputDirectory, name))
+	}
+
+	var errs []error
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// cleanupFailedBuild removes the generated files of the failed build. The files of a failed build are kept for inspection,
+// except for the secrets. The files of a cancelled build are all removed.
+func (k *KubeEleven) cleanupFailedBuild(cancelled bool) error {
+	if cancelled {
+		return k.removeOutputDirectory()
+	}
+	return k.removeSecretFiles()
+}
+
+// removeOutputDirectory removes the output directory, retrying for a grace period,
+// i.e. while the files are still held by an exiting subprocess.
+func (k *KubeEleven) removeOutputDirectory() error {
+	return removeAll(k.outputDirectory)
+}
+
+// removeAll removes the directory, retrying for the cleanupGracePeriod.
+func removeAll(dir string) error {
+	deadline := time.Now().Add(cleanupGracePeriod)
+	for {
+		err := os.RemoveAll(dir)
+		if err == nil || time.Now().Add(cleanupRetryDelay).After(deadline) {
+			return err
+		}
+		time.Sleep(cleanupRetryDelay)
+	}
+}
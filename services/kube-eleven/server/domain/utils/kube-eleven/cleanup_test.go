@@ -0,0 +1,53 @@
+package kube_eleven
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRemoveSecretFiles(t *testing.T) {
+	k := &KubeEleven{}
+	generateTestFiles(t, k)
+
+	// The files written by kubeone apply.
+	for _, name := range []string{"test-cluster-kubeconfig"} {
+		path := filepath.Join(k.outputDirectory, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := k.removeSecretFiles(); err != nil {
+		t.Fatalf("removeSecretFiles() error = %v", err)
+	}
+
+	var got []string
+	err := filepath.WalkDir(k.outputDirectory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(k.outputDirectory, path)
+		got = append(got, rel)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+
+	want := []string{
+		ownerFileName,
+		identityFileName,
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files after removeSecretFiles() = %v, want %v", got, want)
+	}
+}
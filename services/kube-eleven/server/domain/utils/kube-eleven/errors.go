@@ -0,0 +1,17 @@
+package kube_eleven
+
+import "errors"
+
+var (
+	// ErrInvalidConfiguration is returned when the cluster along with the options does not pass the validation.
+	// Retrying the build does not help in this case.
+	ErrInvalidConfiguration = errors.New("invalid configuration")
+
+	// ErrNoAPIEndpoint is returned when the cluster has no api endpoint, i.e. none of the control nodes has
+	// an address assigned yet. Unlike the ErrInvalidConfiguration, a subsequent build may succeed.
+	ErrNoAPIEndpoint = errors.New("cluster has no api endpoint")
+
+	// ErrOutputDirectoryConflict is returned when the output directory of the cluster already exists
+	// and belongs to a different cluster instance.
+	ErrOutputDirectoryConflict = errors.New("output directory belongs to a different cluster")
+)
@@ -1,12 +1,20 @@
 package kube_eleven
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/berops/claudie/internal/templateUtils"
 	"github.com/berops/claudie/internal/utils"
@@ -19,14 +27,22 @@ import (
 const (
 	generatedKubeoneManifestName = "kubeone.yaml"
 	sshKeyFileName               = "private.pem"
-	baseDirectory                = "services/kube-eleven/server"
-	outputDirectory              = "clusters"
 	staticRegion                 = "on-premise"
 	staticZone                   = "datacenter"
 	staticProvider               = "on-premise"
 	staticProviderName           = "claudie"
 )
 
+var (
+	// baseDirectory is the directory in which the outputDirectory is created.
+	baseDirectory = utils.GetEnvDefault("KUBE_ELEVEN_BASE_DIRECTORY", "services/kube-eleven/server")
+	// outputDirectory is the directory, relative to the base directory, holding the files generated for each cluster.
+	outputDirectory = utils.GetEnvDefault("KUBE_ELEVEN_OUTPUT_DIRECTORY", "clusters")
+	// nodepoolWorkers bounds the number of nodepools processed concurrently by the getClusterNodes.
+	nodepoolWorkers = runtime.GOMAXPROCS(0)
+)
+
+// KubeEleven builds and destroys the Kubernetes cluster with kubeone.
 type KubeEleven struct {
 	// Directory where files needed by Kubeone will be generated from templates.
 	outputDirectory string
@@ -36,32 +52,125 @@ type KubeEleven struct {
 	// LB clusters attached to the above Kubernetes cluster.
 	// If nil, the first control node becomes the api endpoint of the cluster.
 	LBClusters []*pb.LBcluster
+	// ProjectName is the name of the project the cluster belongs to. Along with the cluster ID
+	// it identifies the cluster instance owning the output directory.
+	ProjectName string
 
 	// SpawnProcessLimit represents a synchronization channel which limits the number of spawned kubeone
 	// processes. This values must be non-nil and be buffered, where the capacity indicates
 	// the limit.
 	SpawnProcessLimit chan struct{}
+
+	// AllowNoWorkers allows building a cluster consisting only of the control plane nodes.
+	// If false, BuildCluster fails early when the cluster has no worker nodes. The kube-eleven service
+	// sets it for the clusters which do not expect any workers, see ExpectsWorkers.
+	AllowNoWorkers bool
+
+	// kubeconfig is the parsed kubeconfig fetched after kubeone apply.
+	kubeconfig *clientcmdapi.Config
+
+	// progress tracks how far the current build got.
+	progress buildProgress
 }
 
 // BuildCluster is responsible for managing the given K8sCluster along with the attached LBClusters
 // using Kubeone.
 func (k *KubeEleven) BuildCluster() error {
+	return k.BuildClusterContext(context.Background())
+}
+
+// BuildClusterContext is like BuildCluster, but stops the build once the ctx is done, killing a running
+// kubeone apply. In that case a *BuildCancelledError describing the progress of the build is returned.
+func (k *KubeEleven) BuildClusterContext(ctx context.Context) error {
 	clusterID := commonUtils.GetClusterID(k.K8sCluster.ClusterInfo)
 
-	k.outputDirectory = filepath.Join(baseDirectory, outputDirectory, clusterID)
+	dir, err := k.OutputDir()
+	if err != nil {
+		return err
+	}
+	k.outputDirectory = dir
+
+	// Checked only on build, so that a cluster without workers can still be destroyed.
+	if !k.AllowNoWorkers && !k.hasWorkers() {
+		return fmt.Errorf("%w: cluster %s has no worker nodes, check whether the worker nodepools were provisioned", ErrInvalidConfiguration, k.K8sCluster.ClusterInfo.Name)
+	}
+
+	k.progress = buildProgress{}
+	if err := k.buildCluster(ctx, clusterID); err != nil {
+		if ctx.Err() != nil {
+			err = k.cancelledError(err)
+		}
+		if rerr := k.cleanupFailedBuild(ctx.Err() != nil); rerr != nil {
+			k.logEvent(zerolog.WarnLevel, stageBuild).Err(rerr).Msg("Failed to clean up files of the failed build")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// buildCluster executes the build of the BuildClusterContext.
+func (k *KubeEleven) buildCluster(ctx context.Context, clusterID string) error {
 	// Generate files which will be needed by Kubeone.
+	k.enterPhase(BuildPhaseGenerateFiles)
 	err := k.generateFiles()
 	if err != nil {
 		return fmt.Errorf("error while generating files for %s : %w", k.K8sCluster.ClusterInfo.Name, err)
 	}
+	k.progress.filesGenerated = true
+
+	nodepools, _ := k.getClusterNodes()
+
+	// On an already running cluster (i.e. state lost after a crash) only reconcile it.
+	bootstrapped := k.controlPlaneBootstrapped()
+	if bootstrapped {
+		k.logEvent(zerolog.InfoLevel, stageBootstrap).Msg("Control plane is already bootstrapped, reconciling the existing cluster")
+	}
+
+	if err := k.apply(ctx, clusterID, nodepools, bootstrapped); err != nil {
+		return err
+	}
+
+	k.enterPhase(BuildPhasePostApply)
+	if err := k.cancelled(ctx); err != nil {
+		return err
+	}
+
+	// Kuber patches the node metadata as well, thus failing here does not invalidate the successfully built cluster.
+	if err := k.reconcileNodeMetadata(); err != nil {
+		k.logEvent(zerolog.ErrorLevel, stageNodeMetadata).Err(err).Msg("Failed to reconcile labels and taints of the nodes")
+	}
+
+	// Clean up - remove generated files
+	if err := k.removeOutputDirectory(); err != nil {
+		return fmt.Errorf("error while removing files from %s: %w", k.outputDirectory, err)
+	}
+
+	return nil
+}
+
+// apply prepares the nodes from the nodepools and executes kubeone apply with the generated files,
+// updating the kubeconfig of the k.K8sCluster afterwards. If reconcile is set, the control plane
+// is already bootstrapped.
+func (k *KubeEleven) apply(ctx context.Context, clusterID string, nodepools []*NodepoolInfo, reconcile bool) error {
+	k.enterPhase(BuildPhaseApply)
+	if err := k.cancelled(ctx); err != nil {
+		return err
+	}
+
+	// Wait for the infrastructure to settle before executing Kubeone.
+	if err := k.waitForInfrastructure(nodepools); err != nil {
+		return fmt.Errorf("error while waiting for infrastructure of %s : %w", k.K8sCluster.ClusterInfo.Name, err)
+	}
 
 	// Execute Kubeone apply
 	kubeone := kubeone.Kubeone{
 		ConfigDirectory:   k.outputDirectory,
 		SpawnProcessLimit: k.SpawnProcessLimit,
+		Logger:            k.logger(),
+		Reconcile:         reconcile,
 	}
-	err = kubeone.Apply(clusterID)
-	if err != nil {
+	if err := kubeone.ApplyContext(ctx, clusterID); err != nil {
 		return fmt.Errorf("error while running \"kubeone apply\" in %s : %w", k.outputDirectory, err)
 	}
 
@@ -72,23 +181,59 @@ func (k *KubeEleven) BuildCluster() error {
 	if err != nil {
 		return fmt.Errorf("error while reading cluster-config in %s : %w", k.outputDirectory, err)
 	}
-	if len(kubeconfigAsString) > 0 {
-		// Update kubeconfig in the target K8sCluster data structure.
-		k.K8sCluster.Kubeconfig = kubeconfigAsString
+
+	if err := k.updateKubeconfig(kubeconfigAsString); err != nil {
+		return err
 	}
+	k.progress.kubeconfigFetched = true
 
-	// Clean up - remove generated files
-	if err := os.RemoveAll(k.outputDirectory); err != nil {
-		return fmt.Errorf("error while removing files from %s: %w", k.outputDirectory, err)
+	return nil
+}
+
+// updateKubeconfig updates the kubeconfig of the k.K8sCluster with the fetched one, if not empty.
+func (k *KubeEleven) updateKubeconfig(kubeconfigAsString string) error {
+	if len(kubeconfigAsString) == 0 {
+		return nil
+	}
+
+	config, err := clientcmd.Load([]byte(kubeconfigAsString))
+	if err != nil {
+		return fmt.Errorf("error while parsing kubeconfig of %s : %w", k.K8sCluster.ClusterInfo.Name, err)
 	}
+	// Update kubeconfig in the target K8sCluster data structure.
+	k.K8sCluster.Kubeconfig = kubeconfigAsString
+	k.kubeconfig = config
 
 	return nil
 }
 
+// OutputDir returns the directory into which the files for the cluster are generated. The directory is named
+// after the cluster ID followed by a short token derived from the project and the cluster ID, so that the
+// clusters of different projects never share it. Returns ErrOutputDirectoryConflict if the directory already
+// exists and belongs to a different cluster instance.
+func (k *KubeEleven) OutputDir() (string, error) {
+	identity := k.clusterIdentity()
+	dir := filepath.Join(baseDirectory, outputDirectory, fmt.Sprintf("%s-%s", commonUtils.GetClusterID(k.K8sCluster.ClusterInfo), identityToken(identity)))
+
+	owner, err := readIdentity(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read identity of %s: %w", dir, err)
+	}
+	if owner != "" && owner != identity {
+		return "", fmt.Errorf("%w: %s belongs to %s", ErrOutputDirectoryConflict, dir, owner)
+	}
+
+	return dir, nil
+}
+
 func (k *KubeEleven) DestroyCluster() error {
 	clusterID := commonUtils.GetClusterID(k.K8sCluster.ClusterInfo)
 
-	k.outputDirectory = filepath.Join(baseDirectory, outputDirectory, clusterID)
+	dir, err := k.OutputDir()
+	if err != nil {
+		return err
+	}
+	k.outputDirectory = dir
 
 	if err := k.generateFiles(); err != nil {
 		return fmt.Errorf("error while generating files for %s: %w", k.K8sCluster.ClusterInfo.Name, err)
@@ -97,21 +242,76 @@ func (k *KubeEleven) DestroyCluster() error {
 	kubeone := kubeone.Kubeone{
 		ConfigDirectory:   k.outputDirectory,
 		SpawnProcessLimit: k.SpawnProcessLimit,
+		Logger:            k.logger(),
 	}
 
 	// Destroying the cluster might fail when deleting the binaries, if its called subsequently,
 	// thus ignore the error.
 	if err := kubeone.Reset(clusterID); err != nil {
-		log.Warn().Msgf("failed to destroy cluster and remove binaries: %s, assuming they were deleted", err)
+		k.logEvent(zerolog.WarnLevel, stageReset).Err(err).Msg("failed to destroy cluster and remove binaries, assuming they were deleted")
 	}
 
-	if err := os.RemoveAll(k.outputDirectory); err != nil {
+	if err := k.removeOutputDirectory(); err != nil {
 		return fmt.Errorf("error while removing files from %s: %w", k.outputDirectory, err)
 	}
 
 	return nil
 }
 
+// Validate checks that the k.K8sCluster along with the options produce a valid kubeone manifest,
+// without accessing the infrastructure. If the kubeone binary is available, the rendered manifest
+// is additionally validated by kubeone in a temporary directory, which is removed afterwards.
+// Returns all of the encountered errors joined, nil otherwise.
+func (k *KubeEleven) Validate() error {
+	// Work on copies, as generating the template data may modify the nodes.
+	v := k.clone()
+
+	template, err := templateUtils.LoadTemplate(templates.KubeOneTemplate)
+	if err != nil {
+		return fmt.Errorf("error while loading a kubeone template : %w", err)
+	}
+
+	var errs []error
+
+	templateParameters := v.generateTemplateData()
+	if templateParameters.APIVersion, err = v.manifestAPIVersion(); err != nil {
+		errs = append(errs, fmt.Errorf("error while selecting kubeone manifest apiVersion : %w", err))
+	}
+	if templateParameters.APIEndpoint == "" {
+		errs = append(errs, ErrNoAPIEndpoint)
+	}
+	v.checkControlPlaneZones(&templateParameters)
+
+	manifest, err := templateUtils.Templates{}.GenerateToString(template, templateParameters)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error while generating %s from kubeone template : %w", generatedKubeoneManifestName, err))
+		return errors.Join(errs...)
+	}
+
+	if _, err := exec.LookPath("kubeone"); err != nil {
+		return errors.Join(errs...)
+	}
+
+	dir, err := os.MkdirTemp("", "kube-eleven-validate-")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("error while creating temporary directory : %w", err))
+		return errors.Join(errs...)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := os.WriteFile(filepath.Join(dir, generatedKubeoneManifestName), []byte(manifest), 0600); err != nil {
+		errs = append(errs, fmt.Errorf("error while writing %s in %s : %w", generatedKubeoneManifestName, dir, err))
+		return errors.Join(errs...)
+	}
+
+	kubeone := kubeone.Kubeone{ConfigDirectory: dir}
+	if err := kubeone.ValidateConfig(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
 // generateFiles will generate those files (kubeone.yaml and key.pem) needed by Kubeone.
 // Returns nil if successful, error otherwise.
 func (k *KubeEleven) generateFiles() error {
@@ -123,13 +323,42 @@ func (k *KubeEleven) generateFiles() error {
 
 	// Generate templateData for the template.
 	templateParameters := k.generateTemplateData()
+	if templateParameters.APIVersion, err = k.manifestAPIVersion(); err != nil {
+		return fmt.Errorf("error while selecting kubeone manifest apiVersion : %w", err)
+	}
+	k.checkControlPlaneZones(&templateParameters)
+	if templateParameters.APIEndpoint == "" {
+		return ErrNoAPIEndpoint
+	}
 
 	// Generate kubeone.yaml file from the template
-	err = templateUtils.Templates{Directory: k.outputDirectory}.Generate(template, generatedKubeoneManifestName, templateParameters)
+	manifest, err := templateUtils.Templates{Directory: k.outputDirectory}.GenerateToString(template, templateParameters)
 	if err != nil {
 		return fmt.Errorf("error while generating %s from kubeone template : %w", generatedKubeoneManifestName, err)
 	}
 
+	metadata, err := k.generateBuildMetadata()
+	if err != nil {
+		return fmt.Errorf("error while generating build metadata for %s : %w", generatedKubeoneManifestName, err)
+	}
+	manifest = metadata.header() + manifest
+
+	if err := utils.CreateDirectory(k.outputDirectory); err != nil {
+		return fmt.Errorf("failed to create directory %s : %w", k.outputDirectory, err)
+	}
+
+	if err := writeOwner(k.outputDirectory); err != nil {
+		return fmt.Errorf("error while writing %s in %s : %w", ownerFileName, k.outputDirectory, err)
+	}
+
+	if err := writeIdentity(k.outputDirectory, k.clusterIdentity()); err != nil {
+		return fmt.Errorf("error while writing %s in %s : %w", identityFileName, k.outputDirectory, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(k.outputDirectory, generatedKubeoneManifestName), []byte(manifest), 0600); err != nil {
+		return fmt.Errorf("error while writing %s in %s : %w", generatedKubeoneManifestName, k.outputDirectory, err)
+	}
+
 	// Create file containing SSH key which will be used by Kubeone.
 	if err := utils.CreateKeyFile(k.K8sCluster.ClusterInfo.GetPrivateKey(), k.outputDirectory, sshKeyFileName); err != nil {
 		return fmt.Errorf("error while creating SSH key file: %w", err)
@@ -140,6 +369,7 @@ func (k *KubeEleven) generateFiles() error {
 	}
 
 	// Create a kubeconfig file for the target Kubernetes cluster.
+	k.checkStoredKubeconfig()
 	kubeconfigFilePath := filepath.Join(k.outputDirectory, fmt.Sprintf("%s-kubeconfig", k.K8sCluster.ClusterInfo.Name))
 	if err := os.WriteFile(kubeconfigFilePath, []byte(k.K8sCluster.GetKubeconfig()), 0600); err != nil {
 		return fmt.Errorf("error while writing cluster-kubeconfig file in %s: %w", k.outputDirectory, err)
@@ -157,7 +387,9 @@ func (k *KubeEleven) generateTemplateData() templateData {
 	data.Nodepools, potentialEndpointNode = k.getClusterNodes()
 
 	data.APIEndpoint = k.findAPIEndpoint(potentialEndpointNode)
+	data.AlternativeNames = k.findAlternativeNames(data.APIEndpoint)
 
+	data.APIVersion = manifestAPIVersion
 	data.KubernetesVersion = k.K8sCluster.GetKubernetes()
 
 	data.ClusterName = k.K8sCluster.ClusterInfo.Name
@@ -168,61 +400,94 @@ func (k *KubeEleven) generateTemplateData() templateData {
 // getClusterNodes will parse the nodepools of k.K8sCluster and construct a slice of *NodepoolInfo.
 // Returns the slice of *NodepoolInfo and the potential endpoint node.
 func (k *KubeEleven) getClusterNodes() ([]*NodepoolInfo, *pb.Node) {
-	nodepoolInfos := make([]*NodepoolInfo, 0, len(k.K8sCluster.ClusterInfo.NodePools))
-	var endpointNode *pb.Node
+	nodepools := k.K8sCluster.ClusterInfo.GetNodePools()
+	nodepoolInfos := make([]*NodepoolInfo, len(nodepools))
+	// Potential endpoint node of each of the nodepools, reduced in the order of the nodepools once all of them are processed.
+	potentialEndpointNodes := make([]*pb.Node, len(nodepools))
+
+	// Construct the slice of *NodepoolInfo, each of the workers fills only the slots of its nodepool.
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, nodepoolWorkers)
+	for i, nodepool := range nodepools {
+		i, nodepool := i, nodepool
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer func() { <-workers; wg.Done() }()
+			nodepoolInfos[i], potentialEndpointNodes[i] = k.getNodepoolInfo(nodepool)
+		}()
+	}
+	wg.Wait()
 
-	// Construct the slice of *NodepoolInfo
-	for _, nodepool := range k.K8sCluster.ClusterInfo.GetNodePools() {
-		var nodepoolInfo *NodepoolInfo
+	// Skip the nodepools which are neither dynamic nor static.
+	result := make([]*NodepoolInfo, 0, len(nodepoolInfos))
+	for _, nodepoolInfo := range nodepoolInfos {
+		if nodepoolInfo != nil {
+			result = append(result, nodepoolInfo)
+		}
+	}
 
-		if nodepool.GetDynamicNodePool() != nil {
-			var nodes []*NodeInfo
-			nodes, potentialEndpointNode := getNodeData(nodepool.Nodes, func(name string) string {
-				return strings.TrimPrefix(name, fmt.Sprintf("%s-%s-", k.K8sCluster.ClusterInfo.Name, k.K8sCluster.ClusterInfo.Hash))
-			})
+	var endpointNode *pb.Node
+	for _, potentialEndpointNode := range potentialEndpointNodes {
+		if endpointNode == nil || (potentialEndpointNode != nil && potentialEndpointNode.NodeType == pb.NodeType_apiEndpoint) {
+			endpointNode = potentialEndpointNode
+		}
+	}
 
-			if endpointNode == nil || (potentialEndpointNode != nil && potentialEndpointNode.NodeType == pb.NodeType_apiEndpoint) {
-				endpointNode = potentialEndpointNode
-			}
+	return result, endpointNode
+}
 
-			nodepoolInfo = &NodepoolInfo{
-				NodepoolName:      nodepool.Name,
-				Region:            utils.SanitiseString(nodepool.GetDynamicNodePool().Region),
-				Zone:              utils.SanitiseString(nodepool.GetDynamicNodePool().Zone),
-				CloudProviderName: utils.SanitiseString(nodepool.GetDynamicNodePool().Provider.CloudProviderName),
-				ProviderName:      utils.SanitiseString(nodepool.GetDynamicNodePool().Provider.SpecName),
-				Nodes:             nodes,
-				IsDynamic:         true,
-			}
-		} else if nodepool.GetStaticNodePool() != nil {
-			var nodes []*NodeInfo
-			nodes, potentialEndpointNode := getNodeData(nodepool.Nodes, func(s string) string { return s })
-			if endpointNode == nil || (potentialEndpointNode != nil && potentialEndpointNode.NodeType == pb.NodeType_apiEndpoint) {
-				endpointNode = potentialEndpointNode
-			}
-			nodepoolInfo = &NodepoolInfo{
-				NodepoolName:      nodepool.Name,
-				Region:            utils.SanitiseString(staticRegion),
-				Zone:              utils.SanitiseString(staticZone),
-				CloudProviderName: utils.SanitiseString(staticProvider),
-				ProviderName:      utils.SanitiseString(staticProviderName),
-				Nodes:             nodes,
-				IsDynamic:         false,
-			}
+// getNodepoolInfo constructs the *NodepoolInfo of the nodepool.
+// Returns the *NodepoolInfo and the potential endpoint node of the nodepool,
+// nil if the nodepool is neither dynamic nor static.
+func (k *KubeEleven) getNodepoolInfo(nodepool *pb.NodePool) (*NodepoolInfo, *pb.Node) {
+	var (
+		nodepoolInfo          *NodepoolInfo
+		potentialEndpointNode *pb.Node
+	)
+
+	if nodepool.GetDynamicNodePool() != nil {
+		var nodes []*NodeInfo
+		prefix := fmt.Sprintf("%s-%s-", k.K8sCluster.ClusterInfo.Name, k.K8sCluster.ClusterInfo.Hash)
+		nodes, potentialEndpointNode = getNodeData(nodepool.Nodes, func(name string) string {
+			return strings.TrimPrefix(name, prefix)
+		})
+
+		nodepoolInfo = &NodepoolInfo{
+			NodepoolName:      nodepool.Name,
+			Region:            utils.SanitiseString(nodepool.GetDynamicNodePool().Region),
+			Zone:              utils.SanitiseString(nodepool.GetDynamicNodePool().Zone),
+			CloudProviderName: utils.SanitiseString(nodepool.GetDynamicNodePool().Provider.CloudProviderName),
+			ProviderName:      utils.SanitiseString(nodepool.GetDynamicNodePool().Provider.SpecName),
+			Nodes:             nodes,
+			IsDynamic:         true,
+		}
+	} else if nodepool.GetStaticNodePool() != nil {
+		var nodes []*NodeInfo
+		nodes, potentialEndpointNode = getNodeData(nodepool.Nodes, func(s string) string { return s })
+		nodepoolInfo = &NodepoolInfo{
+			NodepoolName:      nodepool.Name,
+			Region:            utils.SanitiseString(staticRegion),
+			Zone:              utils.SanitiseString(staticZone),
+			CloudProviderName: utils.SanitiseString(staticProvider),
+			ProviderName:      utils.SanitiseString(staticProviderName),
+			Nodes:             nodes,
+			IsDynamic:         false,
 		}
-		nodepoolInfos = append(nodepoolInfos, nodepoolInfo)
+	} else {
+		return nil, nil
 	}
 
-	return nodepoolInfos, endpointNode
+	return nodepoolInfo, potentialEndpointNode
 }
 
 // findAPIEndpoint returns the cluster api endpoint.
 // It loops through the slice of attached LB clusters and if any ApiServer type LB cluster is found,
 // then it's DNS endpoint is returned as the cluster api endpoint.
 // Otherwise returns the public IP of the potential endpoint node found in getClusterNodes( ).
+// The node types are owned by ansibler, which promotes and demotes the apiEndpoint node as the LB clusters
+// change, thus a node is marked as the apiEndpoint node only if the cluster has none yet.
 func (k *KubeEleven) findAPIEndpoint(potentialEndpointNode *pb.Node) string {
-	apiEndpoint := ""
-
 	for _, lbCluster := range k.LBClusters {
 		// If the LB cluster is attached to out target Kubernetes cluster
 		if lbCluster.TargetedK8S == k.K8sCluster.ClusterInfo.Name {
@@ -237,14 +502,91 @@ func (k *KubeEleven) findAPIEndpoint(potentialEndpointNode *pb.Node) string {
 
 	// If any LB cluster of type ApiServer is not found
 	// Then we will use the potential endpoint type control node.
-	if potentialEndpointNode != nil {
-		apiEndpoint = potentialEndpointNode.Public
-		potentialEndpointNode.NodeType = pb.NodeType_apiEndpoint
-	} else {
-		log.Error().Msgf("Cluster %s does not have any API endpoint specified", k.K8sCluster.ClusterInfo.Name)
+	if potentialEndpointNode == nil {
+		k.logEvent(zerolog.ErrorLevel, stageAPIEndpoint).Msg("Cluster does not have any API endpoint specified")
+		return ""
+	}
+
+	// The addresses are assigned asynchronously, thus the node might not have one yet.
+	if potentialEndpointNode.Public == "" {
+		// The apiEndpoint node is reassigned only by ansibler.
+		if potentialEndpointNode.GetNodeType() == pb.NodeType_apiEndpoint {
+			k.logEvent(zerolog.ErrorLevel, stageAPIEndpoint).Str("node", potentialEndpointNode.GetName()).Msg("API endpoint node has no address")
+			return ""
+		}
+		k.logEvent(zerolog.WarnLevel, stageAPIEndpoint).Str("node", potentialEndpointNode.GetName()).Msg("API endpoint node has no address, selecting the next control node")
+		if potentialEndpointNode = k.firstControlNode(); potentialEndpointNode == nil {
+			k.logEvent(zerolog.ErrorLevel, stageAPIEndpoint).Msg("None of the control nodes has an address for the API endpoint")
+			return ""
+		}
+	}
+
+	return k.useEndpointNode(potentialEndpointNode)
+}
+
+// firstControlNode returns the first control node of the k.K8sCluster, in the order of the nodepools,
+// which has a public address. The nodes without one, i.e. not yet assigned, are skipped.
+func (k *KubeEleven) firstControlNode() *pb.Node {
+	for _, nodepool := range k.K8sCluster.GetClusterInfo().GetNodePools() {
+		for _, node := range nodepool.GetNodes() {
+			if node.GetNodeType() >= pb.NodeType_master && node.Public != "" {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// apiEndpointNode returns the apiEndpoint node of the k.K8sCluster, nil if there is none.
+func (k *KubeEleven) apiEndpointNode() *pb.Node {
+	for _, nodepool := range k.K8sCluster.GetClusterInfo().GetNodePools() {
+		for _, node := range nodepool.GetNodes() {
+			if node.GetNodeType() == pb.NodeType_apiEndpoint {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// useEndpointNode returns the address of the node as the api endpoint. The node is marked as the apiEndpoint node
+// only if the cluster has none yet, i.e. on its first build, as ansibler reassigns it once the cluster exists.
+// Returns an empty string if the node is nil or differs from the apiEndpoint node of the cluster.
+func (k *KubeEleven) useEndpointNode(node *pb.Node) string {
+	if node == nil {
+		return ""
+	}
+	switch endpointNode := k.apiEndpointNode(); {
+	case endpointNode == nil:
+		node.NodeType = pb.NodeType_apiEndpoint
+	case endpointNode != node:
+		k.logEvent(zerolog.ErrorLevel, stageAPIEndpoint).Str("node", node.GetName()).Msgf("Node %s is already the API endpoint node", endpointNode.GetName())
+		return ""
+	}
+	return node.Public
+}
+
+// findAlternativeNames returns the DNS endpoints of all of the LB clusters attached to the cluster,
+// other than the apiEndpoint, sorted. These are added to the API server certificate so that the API
+// can be reached through any of the LB clusters.
+func (k *KubeEleven) findAlternativeNames(apiEndpoint string) []string {
+	names := make(map[string]struct{})
+	for _, lbCluster := range k.LBClusters {
+		if lbCluster.TargetedK8S != k.K8sCluster.ClusterInfo.Name {
+			continue
+		}
+		if endpoint := lbCluster.GetDns().GetEndpoint(); endpoint != "" && endpoint != apiEndpoint {
+			names[endpoint] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
 	}
+	sort.Strings(result)
 
-	return apiEndpoint
+	return result
 }
 
 // getNodeData return template data for the nodes from the cluster.
@@ -0,0 +1,250 @@
+package kube_eleven
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/berops/claudie/proto/pb"
+)
+
+// kubeoneClusterFields and kubeoneHostFields are the fields of the KubeOneCluster and the HostConfig of the
+// kubeone.k8c.io/v1beta2 API of the kubeone release in the kube-eleven image. kubeone ignores unknown fields,
+// thus a setting rendered outside of them never reaches the cluster.
+var (
+	kubeoneClusterFields = []string{
+		"apiVersion", "kind", "name", "controlPlane", "apiEndpoint", "cloudProvider", "versions", "containerRuntime",
+		"clusterNetwork", "proxy", "staticWorkers", "dynamicWorkers", "machineController", "operatingSystemManager",
+		"caBundle", "features", "addons", "helmReleases", "systemPackages", "registryConfiguration", "loggingConfig",
+	}
+	kubeoneHostFields = []string{
+		"publicAddress", "ipv6Addresses", "privateAddress", "sshPort", "sshUsername", "sshPrivateKeyFile", "sshHostPublicKey",
+		"sshAgentSocket", "bastion", "bastionPort", "bastionUser", "bastionHostPublicKey", "hostname", "isLeader",
+		"taints", "labels", "kubelet", "operatingSystem",
+	}
+)
+
+func testCluster() *pb.K8Scluster {
+	return &pb.K8Scluster{
+		Kubernetes: "1.26.3",
+		ClusterInfo: &pb.ClusterInfo{
+			Name:       "test-cluster",
+			Hash:       "abcdef",
+			PrivateKey: "private-key",
+			NodePools: []*pb.NodePool{
+				{
+					Name:      "control",
+					IsControl: true,
+					Nodes: []*pb.Node{
+						{Name: "control-1", Public: "1.1.1.1", Private: "192.168.2.1", NodeType: pb.NodeType_master},
+						{Name: "control-2", Public: "1.1.1.2", Private: "192.168.2.2", NodeType: pb.NodeType_master},
+					},
+					NodePoolType: &pb.NodePool_StaticNodePool{StaticNodePool: &pb.StaticNodePool{}},
+				},
+				{
+					Name: "compute",
+					Nodes: []*pb.Node{
+						{Name: "compute-1", Public: "1.1.1.3", Private: "192.168.2.3", NodeType: pb.NodeType_worker},
+					},
+					NodePoolType: &pb.NodePool_StaticNodePool{StaticNodePool: &pb.StaticNodePool{}},
+				},
+			},
+		},
+	}
+}
+
+// generateTestFiles generates the files of the k into a temporary output directory, using the testCluster
+// if the k has no cluster, and returns the rendered kubeone manifest.
+func generateTestFiles(t *testing.T, k *KubeEleven) map[string]any {
+	t.Helper()
+
+	if k.K8sCluster == nil {
+		k.K8sCluster = testCluster()
+	}
+	k.outputDirectory = t.TempDir()
+	if err := k.generateFiles(); err != nil {
+		t.Fatalf("generateFiles() error = %v", err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(k.outputDirectory, generatedKubeoneManifestName))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", generatedKubeoneManifestName, err)
+	}
+	var manifest map[string]any
+	if err := yaml.Unmarshal(b, &manifest); err != nil {
+		t.Fatalf("failed to parse %s: %v", generatedKubeoneManifestName, err)
+	}
+
+	checkKnownFields(t, "", manifest, kubeoneClusterFields)
+	for _, group := range []string{"controlPlane", "staticWorkers"} {
+		hosts, _ := manifest[group].(map[string]any)["hosts"].([]any)
+		for _, host := range hosts {
+			checkKnownFields(t, group+".hosts", host.(map[string]any), kubeoneHostFields)
+		}
+	}
+
+	return manifest
+}
+
+// checkKnownFields fails the test if the object has a field, other than the known ones, which kubeone would ignore.
+func checkKnownFields(t *testing.T, path string, object map[string]any, known []string) {
+	t.Helper()
+	for field := range object {
+		if !slices.Contains(known, field) {
+			t.Errorf("%s has field %q unknown to kubeone", generatedKubeoneManifestName, path+"."+field)
+		}
+	}
+}
+
+func apiServerLB() *pb.LBcluster {
+	return &pb.LBcluster{
+		ClusterInfo: &pb.ClusterInfo{Name: "test-lb"},
+		TargetedK8S: "test-cluster",
+		Roles:       []*pb.Role{{Name: "api", RoleType: pb.RoleType_ApiServer}},
+		Dns:         &pb.DNS{Endpoint: "api.example.com"},
+	}
+}
+
+func endpointNodes(cluster *pb.K8Scluster) []string {
+	var result []string
+	for _, np := range cluster.ClusterInfo.NodePools {
+		for _, n := range np.Nodes {
+			if n.NodeType == pb.NodeType_apiEndpoint {
+				result = append(result, n.Name)
+			}
+		}
+	}
+	return result
+}
+
+func clearPublicAddresses(cluster *pb.K8Scluster, names []string) {
+	for _, np := range cluster.ClusterInfo.NodePools {
+		for _, n := range np.Nodes {
+			for _, name := range names {
+				if n.Name == name {
+					n.Public = ""
+				}
+			}
+		}
+	}
+}
+
+func TestFindAPIEndpoint(t *testing.T) {
+	tests := []struct {
+		Name string
+		// lbs are the LB clusters attached in each of the consecutive builds.
+		lbs [][]*pb.LBcluster
+		// noAddress are the nodes without a public address in the last build.
+		noAddress     []string
+		wantEndpoint  string
+		wantEndpoints []string
+	}{
+		{
+			Name:          "no-lb",
+			lbs:           [][]*pb.LBcluster{nil},
+			wantEndpoint:  "1.1.1.1",
+			wantEndpoints: []string{"control-1"},
+		},
+		{
+			Name:          "no-lb-rebuild",
+			lbs:           [][]*pb.LBcluster{nil, nil},
+			wantEndpoint:  "1.1.1.1",
+			wantEndpoints: []string{"control-1"},
+		},
+		{
+			Name:          "lb",
+			lbs:           [][]*pb.LBcluster{{apiServerLB()}},
+			wantEndpoint:  "api.example.com",
+			wantEndpoints: nil,
+		},
+		{
+			// The apiEndpoint node is demoted by ansibler, not by kube-eleven.
+			Name:          "lb-added-later-leaves-demotion-to-ansibler",
+			lbs:           [][]*pb.LBcluster{nil, {apiServerLB()}},
+			wantEndpoint:  "api.example.com",
+			wantEndpoints: []string{"control-1"},
+		},
+		{
+			Name:          "lb-removed-later",
+			lbs:           [][]*pb.LBcluster{{apiServerLB()}, nil},
+			wantEndpoint:  "1.1.1.1",
+			wantEndpoints: []string{"control-1"},
+		},
+		{
+			Name:          "no-address-fallback",
+			lbs:           [][]*pb.LBcluster{nil},
+			noAddress:     []string{"control-1"},
+			wantEndpoint:  "1.1.1.2",
+			wantEndpoints: []string{"control-2"},
+		},
+		{
+			Name:          "no-address-endpoint-node-later",
+			lbs:           [][]*pb.LBcluster{nil, nil},
+			noAddress:     []string{"control-1"},
+			wantEndpoint:  "",
+			wantEndpoints: []string{"control-1"},
+		},
+		{
+			Name:          "no-address-none",
+			lbs:           [][]*pb.LBcluster{nil},
+			noAddress:     []string{"control-1", "control-2"},
+			wantEndpoint:  "",
+			wantEndpoints: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			cluster := testCluster()
+
+			var got string
+			for i, lbs := range tt.lbs {
+				k := &KubeEleven{K8sCluster: cluster, LBClusters: lbs}
+				if i == len(tt.lbs)-1 {
+					clearPublicAddresses(cluster, tt.noAddress)
+				}
+				_, endpointNode := k.getClusterNodes()
+				got = k.findAPIEndpoint(endpointNode)
+			}
+
+			if got != tt.wantEndpoint {
+				t.Errorf("findAPIEndpoint() = %v, want %v", got, tt.wantEndpoint)
+			}
+
+			gotEndpoints := endpointNodes(cluster)
+			if len(gotEndpoints) != len(tt.wantEndpoints) {
+				t.Fatalf("apiEndpoint nodes = %v, want %v", gotEndpoints, tt.wantEndpoints)
+			}
+			for i := range gotEndpoints {
+				if gotEndpoints[i] != tt.wantEndpoints[i] {
+					t.Errorf("apiEndpoint nodes = %v, want %v", gotEndpoints, tt.wantEndpoints)
+				}
+			}
+		})
+	}
+}
+
+func TestGetClusterNodesSkipsUntypedNodepool(t *testing.T) {
+	cluster := testCluster()
+	cluster.ClusterInfo.NodePools = append(cluster.ClusterInfo.NodePools, &pb.NodePool{
+		Name:  "untyped",
+		Nodes: []*pb.Node{{Name: "untyped-1", NodeType: pb.NodeType_worker}},
+	})
+
+	k := &KubeEleven{K8sCluster: cluster}
+	nodepools, endpointNode := k.getClusterNodes()
+
+	if len(nodepools) != 2 {
+		t.Fatalf("getClusterNodes() returned %d nodepools, want 2", len(nodepools))
+	}
+	for _, np := range nodepools {
+		if np.NodepoolName == "untyped" {
+			t.Errorf("getClusterNodes() returned the untyped nodepool")
+		}
+	}
+	if endpointNode.GetName() != "control-1" {
+		t.Errorf("getClusterNodes() endpoint node = %v, want control-1", endpointNode.GetName())
+	}
+}
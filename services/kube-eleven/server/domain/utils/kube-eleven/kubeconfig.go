@@ -0,0 +1,42 @@
+package kube_eleven
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Kubeconfig returns the kubeconfig of the k.K8sCluster fetched by the BuildCluster, already parsed,
+// i.e. for building a client without parsing the raw kubeconfig again. Returns nil before the
+// BuildCluster succeeds. The raw kubeconfig remains available in the k.K8sCluster.
+func (k *KubeEleven) Kubeconfig() *clientcmdapi.Config {
+	return k.kubeconfig
+}
+
+// checkStoredKubeconfig validates the stored kubeconfig of the k.K8sCluster before it is handed to kubeone.
+// An invalid kubeconfig is discarded, so that kubeone fetches a new one instead of failing on it.
+func (k *KubeEleven) checkStoredKubeconfig() {
+	if k.K8sCluster.GetKubeconfig() == "" {
+		return
+	}
+
+	if err := validateKubeconfig(k.K8sCluster.GetKubeconfig()); err != nil {
+		k.logEvent(zerolog.WarnLevel, stageKubeconfig).Err(err).Msg("Stored kubeconfig is invalid, discarding it")
+		k.K8sCluster.Kubeconfig = ""
+	}
+}
+
+// validateKubeconfig checks that the kubeconfig parses and that its current context references
+// an existing cluster with a server and an existing user.
+func validateKubeconfig(kubeconfig string) error {
+	config, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	if err := clientcmd.ConfirmUsable(*config, ""); err != nil {
+		return err
+	}
+	return nil
+}
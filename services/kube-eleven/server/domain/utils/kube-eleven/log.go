@@ -0,0 +1,61 @@
+package kube_eleven
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/berops/claudie/internal/utils"
+)
+
+// Stages of the kube-eleven workflow, attached to every log line as the "stage" field.
+const (
+	stageReset        = "reset"
+	stageAPIEndpoint  = "api-endpoint"
+	stageValidation   = "validation"
+	stageStatus       = "status"
+	stageInfraReady   = "infra-readiness"
+	stageBootstrap    = "bootstrap"
+	stageNodeMetadata = "node-metadata"
+	stageBuild        = "build"
+	stageKubeconfig   = "kubeconfig"
+)
+
+// logger returns a logger with the structured fields describing the k.K8sCluster,
+// i.e. cluster, hash and provider.
+func (k *KubeEleven) logger() zerolog.Logger {
+	return log.With().
+		Str("cluster", k.K8sCluster.ClusterInfo.Name).
+		Str("hash", k.K8sCluster.ClusterInfo.Hash).
+		Str("provider", k.providers()).
+		Logger()
+}
+
+// logEvent returns a log event at the specified level pre-populated with the cluster, hash,
+// provider and stage fields. The caller is responsible for calling Msg/Msgf on the returned event.
+func (k *KubeEleven) logEvent(level zerolog.Level, stage string) *zerolog.Event {
+	l := k.logger()
+	return l.WithLevel(level).Str("stage", stage)
+}
+
+// providers returns the comma separated list of the distinct providers used by the nodepools of the k.K8sCluster.
+func (k *KubeEleven) providers() string {
+	set := make(map[string]struct{})
+	for _, nodepool := range k.K8sCluster.ClusterInfo.GetNodePools() {
+		if np := nodepool.GetDynamicNodePool(); np != nil {
+			set[utils.SanitiseString(np.GetProvider().GetCloudProviderName())] = struct{}{}
+		} else if nodepool.GetStaticNodePool() != nil {
+			set[utils.SanitiseString(staticProvider)] = struct{}{}
+		}
+	}
+
+	providers := make([]string, 0, len(set))
+	for p := range set {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers)
+
+	return strings.Join(providers, ",")
+}
@@ -0,0 +1,93 @@
+package kube_eleven
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/berops/claudie/internal/templateUtils"
+	"github.com/berops/claudie/proto/pb"
+	"github.com/berops/claudie/services/kube-eleven/templates"
+)
+
+// TemplateData is the view of the data the kubeone manifest is rendered from,
+// i.e. to inspect the resolved API endpoint or the classification of the nodes.
+type TemplateData = templateData
+
+// ManifestFormat is the format the kubeone manifest is rendered in by the RenderManifest.
+type ManifestFormat string
+
+const (
+	ManifestFormatYAML ManifestFormat = "yaml"
+	ManifestFormatJSON ManifestFormat = "json"
+)
+
+// RenderManifest renders the kubeone manifest of the k.K8sCluster in the format, YAML if empty, without accessing
+// the infrastructure nor writing any files. The JSON format is meant for inspection by tooling only, as kubeone
+// applies the YAML manifest. Returns ErrInvalidConfiguration if the format is unsupported.
+func (k *KubeEleven) RenderManifest(format ManifestFormat) ([]byte, error) {
+	if format == "" {
+		format = ManifestFormatYAML
+	}
+	if format != ManifestFormatYAML && format != ManifestFormatJSON {
+		return nil, fmt.Errorf("%w: unsupported manifest format %q, expected %q or %q", ErrInvalidConfiguration, format, ManifestFormatYAML, ManifestFormatJSON)
+	}
+
+	// Work on a copy, as generating the template data may modify the nodes.
+	v := k.clone()
+
+	template, err := templateUtils.LoadTemplate(templates.KubeOneTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading a kubeone template : %w", err)
+	}
+
+	templateParameters := v.generateTemplateData()
+	if templateParameters.APIVersion, err = v.manifestAPIVersion(); err != nil {
+		return nil, fmt.Errorf("error while selecting kubeone manifest apiVersion : %w", err)
+	}
+
+	manifest, err := templateUtils.Templates{}.GenerateToString(template, templateParameters)
+	if err != nil {
+		return nil, fmt.Errorf("error while generating %s from kubeone template : %w", generatedKubeoneManifestName, err)
+	}
+
+	if format == ManifestFormatYAML {
+		return []byte(manifest), nil
+	}
+
+	var content any
+	if err := yaml.Unmarshal([]byte(manifest), &content); err != nil {
+		return nil, fmt.Errorf("error while parsing %s : %w", generatedKubeoneManifestName, err)
+	}
+	b, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error while converting %s to json : %w", generatedKubeoneManifestName, err)
+	}
+
+	return b, nil
+}
+
+// TemplateData returns the fully populated data the kubeone manifest of the k.K8sCluster is rendered from, without
+// rendering nor validating it, so that the data of an invalid configuration can be inspected as well.
+func (k *KubeEleven) TemplateData() (TemplateData, error) {
+	// Work on a copy, as generating the template data may modify the nodes.
+	v := k.clone()
+
+	data := v.generateTemplateData()
+
+	return data, nil
+}
+
+// clone returns a copy of the KubeEleven with copies of the cluster and the LB clusters, which
+// generating the template data may modify.
+func (k *KubeEleven) clone() *KubeEleven {
+	v := *k
+	v.K8sCluster = proto.Clone(k.K8sCluster).(*pb.K8Scluster)
+	v.LBClusters = make([]*pb.LBcluster, 0, len(k.LBClusters))
+	for _, lb := range k.LBClusters {
+		v.LBClusters = append(v.LBClusters, proto.Clone(lb).(*pb.LBcluster))
+	}
+	return &v
+}
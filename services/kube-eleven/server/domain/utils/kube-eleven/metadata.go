@@ -0,0 +1,77 @@
+package kube_eleven
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/berops/claudie/internal/utils"
+	"github.com/berops/claudie/proto/pb"
+)
+
+// claudieVersion is the version of Claudie that is recorded in the generated files.
+var claudieVersion = utils.GetEnvDefault("CLAUDIE_VERSION", "unknown")
+
+// BuildMetadata holds information about the build which produced the generated files.
+type BuildMetadata struct {
+	ClaudieVersion string
+	BuildTimestamp string
+	ClusterHash    string
+	SpecHash       string
+}
+
+// generateBuildMetadata creates the build metadata for the k.K8sCluster.
+func (k *KubeEleven) generateBuildMetadata() (BuildMetadata, error) {
+	specHash, err := k.specHash()
+	if err != nil {
+		return BuildMetadata{}, err
+	}
+
+	return BuildMetadata{
+		ClaudieVersion: claudieVersion,
+		BuildTimestamp: time.Now().UTC().Format(time.RFC3339),
+		ClusterHash:    k.K8sCluster.ClusterInfo.Hash,
+		SpecHash:       specHash,
+	}, nil
+}
+
+// specHash returns a sha256 hash of the input spec (the K8sCluster along with the attached LBClusters).
+// The kubeconfig is excluded, as it is an output of the build rather than an input.
+func (k *KubeEleven) specHash() (string, error) {
+	opts := proto.MarshalOptions{Deterministic: true}
+	h := sha256.New()
+
+	cluster := proto.Clone(k.K8sCluster).(*pb.K8Scluster)
+	cluster.Kubeconfig = ""
+
+	b, err := opts.Marshal(cluster)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cluster %s: %w", k.K8sCluster.ClusterInfo.Name, err)
+	}
+	h.Write(b)
+
+	for _, lb := range k.LBClusters {
+		b, err := opts.Marshal(lb)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal loadbalancer %s: %w", lb.GetClusterInfo().GetName(), err)
+		}
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// header returns the metadata formatted as a block of YAML comments.
+func (m BuildMetadata) header() string {
+	b := new(strings.Builder)
+	b.WriteString("# Generated by Claudie kube-eleven. DO NOT EDIT.\n")
+	b.WriteString(fmt.Sprintf("# claudie-version: %s\n", m.ClaudieVersion))
+	b.WriteString(fmt.Sprintf("# build-timestamp: %s\n", m.BuildTimestamp))
+	b.WriteString(fmt.Sprintf("# cluster-hash: %s\n", m.ClusterHash))
+	b.WriteString(fmt.Sprintf("# spec-hash: %s\n", m.SpecHash))
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package kube_eleven
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/berops/claudie/internal/kubectl"
+	"github.com/berops/claudie/internal/nodes"
+	"github.com/berops/claudie/internal/utils"
+)
+
+// systemTaintPrefixes are the prefixes of the taints managed by kubernetes itself,
+// which are preserved during the reconciliation.
+var systemTaintPrefixes = []string{"node.kubernetes.io/", "node.cloudprovider.kubernetes.io/"}
+
+// reconcileNodeMetadata patches the labels and taints of the live nodes of the cluster to match
+// the desired nodepools, so that changes of the nodepools also apply to the already joined nodes.
+// Only the nodes whose current state differs are patched.
+func (k *KubeEleven) reconcileNodeMetadata() error {
+	kc := kubectl.Kubectl{Kubeconfig: k.K8sCluster.GetKubeconfig(), MaxKubectlRetries: 3}
+	out, err := kc.KubectlGet("nodes", "-o", "json")
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	var list corev1.NodeList
+	if err := json.Unmarshal(out, &list); err != nil {
+		return fmt.Errorf("failed to parse nodes: %w", err)
+	}
+
+	live := make(map[string]*corev1.Node, len(list.Items))
+	for i := range list.Items {
+		live[list.Items[i].Name] = &list.Items[i]
+	}
+
+	clusterID := utils.GetClusterID(k.K8sCluster.ClusterInfo)
+
+	var errs []error
+	for _, np := range k.K8sCluster.ClusterInfo.GetNodePools() {
+		labels, err := nodes.GetAllLabels(np, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create labels for nodepool %s: %w", np.Name, err))
+			continue
+		}
+		taints := nodes.GetAllTaints(np)
+
+		for _, n := range np.GetNodes() {
+			node, ok := live[strings.TrimPrefix(n.Name, fmt.Sprintf("%s-", clusterID))]
+			if !ok {
+				// nodes which did not join are reported by the ClusterStatus.
+				continue
+			}
+
+			if diff := labelsDiff(node.Labels, labels); len(diff) > 0 {
+				patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"labels": diff}})
+				if err != nil {
+					return fmt.Errorf("failed to create labels patch for node %s: %w", node.Name, err)
+				}
+				if err := kc.KubectlPatch("node", node.Name, string(patch)); err != nil {
+					errs = append(errs, fmt.Errorf("failed to patch labels of node %s: %w", node.Name, err))
+				}
+			}
+
+			if desired := desiredTaints(node.Spec.Taints, taints); !equalTaints(node.Spec.Taints, desired) {
+				patch, err := json.Marshal([]map[string]any{{"op": "replace", "path": "/spec/taints", "value": desired}})
+				if err != nil {
+					return fmt.Errorf("failed to create taints patch for node %s: %w", node.Name, err)
+				}
+				if err := kc.KubectlPatch("node", node.Name, string(patch), "--type", "json"); err != nil {
+					errs = append(errs, fmt.Errorf("failed to patch taints of node %s: %w", node.Name, err))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// labelsDiff returns the desired labels which are missing or have a different value in current.
+// The keys of the desired labels may be escaped for use in a JSON patch.
+func labelsDiff(current, desired map[string]string) map[string]string {
+	diff := make(map[string]string)
+	for key, value := range desired {
+		key = strings.ReplaceAll(key, "~1", "/")
+		if v, ok := current[key]; !ok || v != value {
+			diff[key] = value
+		}
+	}
+	return diff
+}
+
+// desiredTaints returns the desired taints extended by the current taints managed by kubernetes itself.
+func desiredTaints(current, desired []corev1.Taint) []corev1.Taint {
+	result := append([]corev1.Taint{}, desired...)
+	for _, t := range current {
+		for _, prefix := range systemTaintPrefixes {
+			if strings.HasPrefix(t.Key, prefix) {
+				result = append(result, t)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// equalTaints reports whether both slices hold the same taints, regardless of their order.
+func equalTaints(a, b []corev1.Taint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, t := range a {
+		found := false
+		for _, o := range b {
+			if t.MatchTaint(&o) && t.Value == o.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
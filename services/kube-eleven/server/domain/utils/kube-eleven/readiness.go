@@ -0,0 +1,58 @@
+package kube_eleven
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/berops/claudie/internal/utils"
+	"github.com/berops/claudie/proto/pb"
+)
+
+const (
+	infraReadyPollInterval = 5 * time.Second
+	sshDialTimeout         = 5 * time.Second
+)
+
+var (
+	// sshPort is the port on which the nodes are reachable via SSH.
+	sshPort = 22
+	// infraReadyTimeout is the maximum time to wait for SSH to become reachable on all of the
+	// control plane nodes before executing kubeone.
+	infraReadyTimeout = 5 * time.Minute
+)
+
+// waitForInfrastructure waits until the SSH port is reachable on all of the control plane nodes,
+// so that kubeone is not executed before the infrastructure has fully settled (firewalls, routes).
+// Returns an error if any of the nodes is not reachable within the infraReadyTimeout.
+func (k *KubeEleven) waitForInfrastructure(nodepools []*NodepoolInfo) error {
+	var nodes []*pb.Node
+	for _, nodepool := range nodepools {
+		for _, nodeInfo := range nodepool.Nodes {
+			if nodeInfo.Node.GetNodeType() >= pb.NodeType_master {
+				nodes = append(nodes, nodeInfo.Node)
+			}
+		}
+	}
+
+	deadline := time.Now().Add(infraReadyTimeout)
+	return utils.ConcurrentExec(nodes, func(_ int, node *pb.Node) error {
+		address := net.JoinHostPort(node.Public, strconv.Itoa(sshPort))
+		for {
+			conn, err := net.DialTimeout("tcp", address, sshDialTimeout)
+			if err == nil {
+				return conn.Close()
+			}
+
+			if time.Now().After(deadline) {
+				return fmt.Errorf("node %s is not reachable on %s after %s: %w", node.Name, address, infraReadyTimeout, err)
+			}
+
+			k.logEvent(zerolog.DebugLevel, stageInfraReady).Str("node", node.Name).Err(err).Msg("Waiting for SSH to become reachable")
+			time.Sleep(infraReadyPollInterval)
+		}
+	})
+}
@@ -0,0 +1,35 @@
+package kube_eleven
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/berops/claudie/proto/pb"
+)
+
+func TestWaitForInfrastructure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	defer func(port int, timeout time.Duration) { sshPort, infraReadyTimeout = port, timeout }(sshPort, infraReadyTimeout)
+	sshPort, _ = strconv.Atoi(port)
+	infraReadyTimeout = 100 * time.Millisecond
+
+	k := &KubeEleven{K8sCluster: testCluster()}
+	nodepools := []*NodepoolInfo{{Nodes: []*NodeInfo{{Name: "control-1", Node: &pb.Node{Name: "control-1", Public: "127.0.0.1", NodeType: pb.NodeType_master}}}}}
+
+	if err := k.waitForInfrastructure(nodepools); err != nil {
+		t.Errorf("waitForInfrastructure() error = %v, want nil", err)
+	}
+
+	listener.Close()
+	if err := k.waitForInfrastructure(nodepools); err == nil {
+		t.Errorf("waitForInfrastructure() on a closed port error = nil, want error")
+	}
+}
@@ -0,0 +1,84 @@
+package kube_eleven
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/berops/claudie/internal/kubectl"
+)
+
+type (
+	// Status is a summary of the nodes which joined the cluster.
+	Status struct {
+		// Ready is the number of nodes in the Ready state.
+		Ready int
+		// NotReady is the number of nodes which joined the cluster but are not Ready.
+		NotReady int
+		// Nodes holds the status of each of the joined nodes.
+		Nodes []NodeStatus
+		// Missing are the nodes expected to be in the cluster which did not join.
+		Missing []string
+	}
+
+	// NodeStatus is the status of a single node of the cluster.
+	NodeStatus struct {
+		Name           string
+		Ready          bool
+		KubeletVersion string
+	}
+)
+
+// ClusterStatus lists the nodes of the cluster using the kubeconfig of the k.K8sCluster
+// and reports their readiness and versions. Meant to be called after BuildCluster.
+func (k *KubeEleven) ClusterStatus() (*Status, error) {
+	kc := kubectl.Kubectl{Kubeconfig: k.K8sCluster.GetKubeconfig(), MaxKubectlRetries: 3}
+	out, err := kc.KubectlGet("nodes", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes of cluster %s: %w", k.K8sCluster.ClusterInfo.Name, err)
+	}
+
+	var nodes corev1.NodeList
+	if err := json.Unmarshal(out, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse nodes of cluster %s: %w", k.K8sCluster.ClusterInfo.Name, err)
+	}
+
+	status := &Status{}
+	joined := make(map[string]struct{}, len(nodes.Items))
+	for _, node := range nodes.Items {
+		ns := NodeStatus{Name: node.Name, KubeletVersion: node.Status.NodeInfo.KubeletVersion}
+		for _, c := range node.Status.Conditions {
+			if c.Type == corev1.NodeReady {
+				ns.Ready = c.Status == corev1.ConditionTrue
+			}
+		}
+
+		if ns.Ready {
+			status.Ready++
+		} else {
+			status.NotReady++
+		}
+
+		status.Nodes = append(status.Nodes, ns)
+		joined[node.Name] = struct{}{}
+	}
+
+	nodepools, _ := k.getClusterNodes()
+	for _, nodepool := range nodepools {
+		for _, nodeInfo := range nodepool.Nodes {
+			if _, ok := joined[nodeInfo.Name]; !ok {
+				status.Missing = append(status.Missing, nodeInfo.Name)
+			}
+		}
+	}
+	sort.Strings(status.Missing)
+
+	if len(status.Missing) > 0 {
+		k.logEvent(zerolog.WarnLevel, stageStatus).Strs("missing", status.Missing).Msg("Some of the expected nodes did not join the cluster")
+	}
+
+	return status, nil
+}
@@ -26,7 +26,9 @@ type (
 	// templateData struct holds the data which will be used in creating
 	// the Kubeone files from templates.
 	templateData struct {
+		APIVersion        string
 		APIEndpoint       string
+		AlternativeNames  []string
 		KubernetesVersion string
 		ClusterName       string
 		Nodepools         []*NodepoolInfo
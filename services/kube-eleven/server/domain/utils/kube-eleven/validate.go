@@ -0,0 +1,63 @@
+package kube_eleven
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog"
+
+	"github.com/berops/claudie/proto/pb"
+)
+
+// hasWorkers returns true if the k.K8sCluster has any worker node.
+func (k *KubeEleven) hasWorkers() bool {
+	for _, nodepool := range k.K8sCluster.GetClusterInfo().GetNodePools() {
+		for _, node := range nodepool.GetNodes() {
+			if node.GetNodeType() == pb.NodeType_worker {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExpectsWorkers returns true if the cluster has any compute nodepool expected to have nodes, i.e. a static
+// nodepool or a dynamic nodepool with a positive count. A cluster without such nodepools is control-plane-only,
+// whereas without the worker nodes of such nodepools their provisioning failed.
+func ExpectsWorkers(cluster *pb.K8Scluster) bool {
+	for _, nodepool := range cluster.GetClusterInfo().GetNodePools() {
+		if nodepool.GetIsControl() {
+			continue
+		}
+		if nodepool.GetStaticNodePool() != nil || nodepool.GetDynamicNodePool().GetCount() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkControlPlaneZones logs a warning if the control plane nodes are not spread across at least two zones,
+// as otherwise a single zone outage takes down the whole control plane.
+func (k *KubeEleven) checkControlPlaneZones(d *templateData) {
+	zones := make(map[string]struct{})
+	for _, nodepool := range d.Nodepools {
+		for _, nodeInfo := range nodepool.Nodes {
+			if nodeInfo.Node.GetNodeType() >= pb.NodeType_master {
+				zones[nodepool.Zone] = struct{}{}
+			}
+		}
+	}
+
+	if len(zones) >= 2 {
+		return
+	}
+
+	spread := make([]string, 0, len(zones))
+	for zone := range zones {
+		spread = append(spread, zone)
+	}
+	sort.Strings(spread)
+
+	k.logEvent(zerolog.WarnLevel, stageValidation).
+		Strs("zones", spread).
+		Msg("Control plane nodes are not spread across at least two zones, a single zone outage will make the cluster unavailable")
+}
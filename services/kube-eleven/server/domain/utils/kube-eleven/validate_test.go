@@ -0,0 +1,51 @@
+package kube_eleven
+
+import (
+	"testing"
+
+	"github.com/berops/claudie/proto/pb"
+)
+
+func TestExpectsWorkers(t *testing.T) {
+	control := &pb.NodePool{Name: "control", IsControl: true, NodePoolType: &pb.NodePool_DynamicNodePool{DynamicNodePool: &pb.DynamicNodePool{Count: 3}}}
+	tests := []struct {
+		Name      string
+		nodepools []*pb.NodePool
+		want      bool
+	}{
+		{
+			Name:      "control-plane-only",
+			nodepools: []*pb.NodePool{control},
+			want:      false,
+		},
+		{
+			Name: "dynamic-compute",
+			nodepools: []*pb.NodePool{control,
+				{Name: "compute", NodePoolType: &pb.NodePool_DynamicNodePool{DynamicNodePool: &pb.DynamicNodePool{Count: 2}}},
+			},
+			want: true,
+		},
+		{
+			Name: "autoscaled-compute-from-zero",
+			nodepools: []*pb.NodePool{control,
+				{Name: "compute", NodePoolType: &pb.NodePool_DynamicNodePool{DynamicNodePool: &pb.DynamicNodePool{Count: 0}}},
+			},
+			want: false,
+		},
+		{
+			Name: "static-compute",
+			nodepools: []*pb.NodePool{control,
+				{Name: "compute", NodePoolType: &pb.NodePool_StaticNodePool{StaticNodePool: &pb.StaticNodePool{}}},
+			},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			cluster := &pb.K8Scluster{ClusterInfo: &pb.ClusterInfo{Name: "test-cluster", NodePools: tt.nodepools}}
+			if got := ExpectsWorkers(cluster); got != tt.want {
+				t.Errorf("ExpectsWorkers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
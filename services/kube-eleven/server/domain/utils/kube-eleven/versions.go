@@ -0,0 +1,80 @@
+package kube_eleven
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/berops/claudie/services/kube-eleven/server/domain/utils/kubeone"
+)
+
+const (
+	// manifestAPIVersion is the apiVersion of the kubeone manifest rendered if the version of kubeone can not be detected,
+	// supported by the kubeone release in the kube-eleven image.
+	manifestAPIVersion = "kubeone.k8c.io/v1beta2"
+)
+
+// manifestAPIVersions are the apiVersions of the kubeone manifest, each used since the first minor kubeone release
+// supporting it, ordered from the newest. The template renders only the fields common to all of them.
+var manifestAPIVersions = []struct {
+	minMinor   int
+	apiVersion string
+}{
+	{minMinor: 7, apiVersion: "kubeone.k8c.io/v1beta3"},
+	{minMinor: 4, apiVersion: "kubeone.k8c.io/v1beta2"},
+}
+
+var (
+	// kubeoneVersionMu guards the kubeoneVersion, detected once per process, as it does not change at runtime.
+	// A failed detection is retried on the next build.
+	kubeoneVersionMu sync.Mutex
+	kubeoneVersion   *kubeone.VersionInfo
+)
+
+// manifestAPIVersion returns the apiVersion of the kubeone manifest for the installed kubeone binary, so that
+// an upgrade of kubeone in the image does not fail cryptically on a schema error. If the version of kubeone can
+// not be detected, i.e. it is not installed, the manifestAPIVersion is used, as kubeone apply fails regardless.
+func (k *KubeEleven) manifestAPIVersion() (string, error) {
+	version, err := k.detectKubeoneVersion()
+	if err != nil {
+		k.logEvent(zerolog.WarnLevel, stageValidation).Err(err).Msgf("Failed to detect the kubeone version, using the apiVersion %s", manifestAPIVersion)
+		return manifestAPIVersion, nil
+	}
+	return manifestAPIVersionFor(version)
+}
+
+// detectKubeoneVersion returns the version of the installed kubeone binary, detecting it on the first successful call.
+func (k *KubeEleven) detectKubeoneVersion() (*kubeone.VersionInfo, error) {
+	kubeoneVersionMu.Lock()
+	defer kubeoneVersionMu.Unlock()
+
+	if kubeoneVersion != nil {
+		return kubeoneVersion, nil
+	}
+	version, err := kubeone.Version()
+	if err != nil {
+		return nil, err
+	}
+	k.logEvent(zerolog.DebugLevel, stageValidation).Str("kubeone", version.GitVersion).Msg("Detected kubeone version")
+	kubeoneVersion = version
+	return version, nil
+}
+
+// manifestAPIVersionFor returns the newest apiVersion of the kubeone manifest supported by the kubeone version.
+// Returns an error if the kubeone version does not support any of the manifestAPIVersions.
+func manifestAPIVersionFor(version *kubeone.VersionInfo) (string, error) {
+	minor, err := strconv.Atoi(strings.TrimSuffix(version.Minor, "+"))
+	if version.Major != "1" || err != nil {
+		return "", fmt.Errorf("installed kubeone %s is not supported, the manifest can be rendered only for kubeone 1.x", version.GitVersion)
+	}
+	for _, v := range manifestAPIVersions {
+		if minor >= v.minMinor {
+			return v.apiVersion, nil
+		}
+	}
+	oldest := manifestAPIVersions[len(manifestAPIVersions)-1]
+	return "", fmt.Errorf("installed kubeone %s is not supported, the manifest apiVersion %s requires at least kubeone 1.%d", version.GitVersion, oldest.apiVersion, oldest.minMinor)
+}
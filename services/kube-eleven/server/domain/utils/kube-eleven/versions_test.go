@@ -0,0 +1,36 @@
+package kube_eleven
+
+import (
+	"testing"
+
+	"github.com/berops/claudie/services/kube-eleven/server/domain/utils/kubeone"
+)
+
+func TestManifestAPIVersionFor(t *testing.T) {
+	tests := []struct {
+		Name    string
+		version kubeone.VersionInfo
+		want    string
+		wantErr bool
+	}{
+		{Name: "kubeone-1.8", version: kubeone.VersionInfo{Major: "1", Minor: "8", GitVersion: "v1.8.0"}, want: "kubeone.k8c.io/v1beta3"},
+		{Name: "kubeone-1.7", version: kubeone.VersionInfo{Major: "1", Minor: "7", GitVersion: "v1.7.0"}, want: "kubeone.k8c.io/v1beta3"},
+		{Name: "kubeone-1.6", version: kubeone.VersionInfo{Major: "1", Minor: "6", GitVersion: "v1.6.2"}, want: "kubeone.k8c.io/v1beta2"},
+		{Name: "kubeone-1.4", version: kubeone.VersionInfo{Major: "1", Minor: "4", GitVersion: "v1.4.0"}, want: "kubeone.k8c.io/v1beta2"},
+		{Name: "kubeone-development-build", version: kubeone.VersionInfo{Major: "1", Minor: "6+", GitVersion: "v1.6.0-dev"}, want: "kubeone.k8c.io/v1beta2"},
+		{Name: "kubeone-1.3", version: kubeone.VersionInfo{Major: "1", Minor: "3", GitVersion: "v1.3.0"}, wantErr: true},
+		{Name: "kubeone-2.0", version: kubeone.VersionInfo{Major: "2", Minor: "0", GitVersion: "v2.0.0"}, wantErr: true},
+		{Name: "unparsable-minor", version: kubeone.VersionInfo{Major: "1", Minor: "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			got, err := manifestAPIVersionFor(&tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("manifestAPIVersionFor() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("manifestAPIVersionFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
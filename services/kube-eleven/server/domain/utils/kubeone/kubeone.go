@@ -2,8 +2,12 @@ package kubeone
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -11,8 +15,12 @@ import (
 	comm "github.com/berops/claudie/internal/command"
 )
 
-// maxRetryCount is max number of retries for kubeone apply.
-const maxRetryCount = 2
+const (
+	// maxRetryCount is max number of retries for kubeone apply.
+	maxRetryCount = 2
+	// maxRetryBackoff is the maximum time to wait between the retries of kubeone apply.
+	maxRetryBackoff = 5 * time.Minute
+)
 
 type Kubeone struct {
 	// ConfigDirectory is the directory where the generated kubeone.yaml will be located.
@@ -21,12 +29,19 @@ type Kubeone struct {
 	// processes. This values must be non-nil and be buffered, where the capacity indicates
 	// the limit.
 	SpawnProcessLimit chan struct{}
+	// Logger is used for logging, pre-populated with the fields describing the cluster.
+	Logger zerolog.Logger
+	// Reconcile signals that the control plane is already bootstrapped, in which case
+	// apply only reconciles the existing cluster and does not re-create the MachineDeployments.
+	Reconcile bool
 }
 
 func (k *Kubeone) Reset(prefix string) error {
 	k.SpawnProcessLimit <- struct{}{}
 	defer func() { <-k.SpawnProcessLimit }()
 
+	logger := k.Logger.With().Str("stage", "reset").Logger()
+
 	output := new(bytes.Buffer)
 
 	command := fmt.Sprintf("kubeone reset -m kubeone.yaml -y --remove-binaries %s", structuredLogging())
@@ -35,7 +50,7 @@ func (k *Kubeone) Reset(prefix string) error {
 	cmd.Stdout = output
 	cmd.Stderr = output
 
-	if log.Logger.GetLevel() == zerolog.DebugLevel {
+	if debugLogging() {
 		cmd.Stdout = comm.GetStdOut(prefix)
 		cmd.Stderr = comm.GetStdErr(prefix)
 	}
@@ -43,15 +58,15 @@ func (k *Kubeone) Reset(prefix string) error {
 	if err := cmd.Run(); err != nil {
 		l, errParse := collectErrors(output)
 		if errParse == nil && len(l) > 0 {
-			log.Error().Msgf("failed to execute cmd: %s: %s", command, l.prettyPrint())
+			logger.Error().Msgf("failed to execute cmd: %s: %s", command, l.prettyPrint())
 		}
 		if errParse != nil {
-			log.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
+			logger.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
 		}
 
 		output.Reset()
 
-		log.Warn().Msgf("Error encountered while executing %s : %v", command, err)
+		logger.Warn().Msgf("Error encountered while executing %s : %v", command, err)
 
 		retryCmd := comm.Cmd{
 			Command: command,
@@ -64,11 +79,11 @@ func (k *Kubeone) Reset(prefix string) error {
 			l, errParse := collectErrors(output)
 			if errParse != nil {
 				output.Reset()
-				log.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
+				logger.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
 				return nil
 			}
 			if len(l) > 0 {
-				log.Error().Msgf("failed to execute cmd: %s: %s", retryCmd.Command, l.prettyPrint())
+				logger.Error().Msgf("failed to execute cmd: %s: %s", retryCmd.Command, l.prettyPrint())
 			}
 			output.Reset()
 			return nil
@@ -77,7 +92,7 @@ func (k *Kubeone) Reset(prefix string) error {
 		if err != nil {
 			l, errParse := collectErrors(output)
 			if errParse != nil {
-				log.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
+				logger.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
 				return fmt.Errorf("failed to execute cmd: %s: %w", retryCmd.Command, err)
 			}
 			if len(l) > 0 {
@@ -93,73 +108,139 @@ func (k *Kubeone) Reset(prefix string) error {
 // Apply will run `kubeone apply -m kubeone.yaml -y` in the ConfigDirectory.
 // Returns nil if successful, error otherwise.
 func (k *Kubeone) Apply(prefix string) error {
+	return k.ApplyContext(context.Background(), prefix)
+}
+
+// ApplyContext is like Apply, but the kubeone process is killed once the ctx is done,
+// in which case the apply is not retried.
+func (k *Kubeone) ApplyContext(ctx context.Context, prefix string) error {
 	k.SpawnProcessLimit <- struct{}{}
 	defer func() { <-k.SpawnProcessLimit }()
 
-	output := new(bytes.Buffer)
+	logger := k.Logger.With().Str("stage", "apply").Logger()
 
-	command := fmt.Sprintf("kubeone apply -m kubeone.yaml -y %s", structuredLogging())
-	cmd := exec.Command("bash", "-c", command)
-	cmd.Dir = k.ConfigDirectory
-	cmd.Stdout = output
-	cmd.Stderr = output
+	output := new(bytes.Buffer)
 
-	if log.Logger.GetLevel() == zerolog.DebugLevel {
-		// Here prefix is the cluster id
-		cmd.Stdout = comm.GetStdOut(prefix)
-		cmd.Stderr = comm.GetStdErr(prefix)
+	command := fmt.Sprintf("kubeone apply -m kubeone.yaml -y %s %s", k.applyFlags(), structuredLogging())
+	run := func() error {
+		cmd := exec.CommandContext(ctx, "bash", "-c", command)
+		cmd.Dir = k.ConfigDirectory
+		cmd.Stdout = output
+		cmd.Stderr = output
+
+		if debugLogging() {
+			// Here prefix is the cluster id
+			cmd.Stdout = comm.GetStdOut(prefix)
+			cmd.Stderr = comm.GetStdErr(prefix)
+		}
+		return cmd.Run()
 	}
 
-	if err := cmd.Run(); err != nil {
+	err := run()
+	for retry := 1; err != nil && retry <= maxRetryCount; retry++ {
+		if ctx.Err() != nil {
+			return fmt.Errorf("cmd %s was cancelled: %w", command, ctx.Err())
+		}
+
 		l, errParse := collectErrors(output)
 		if errParse == nil && len(l) > 0 {
-			log.Error().Msgf("failed to execute cmd: %s: %s", command, l.prettyPrint())
+			logger.Error().Msgf("failed to execute cmd: %s: %s", command, l.prettyPrint())
 		}
 		if errParse != nil {
-			log.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
+			logger.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
 		}
 
 		output.Reset()
 
-		log.Warn().Msgf("Error encountered while executing %s : %v", command, err)
+		backoff := retryBackoff(retry)
+		logger.Warn().Msgf("Error encountered while executing %s : %v, next retry in %s", command, err, backoff)
 
-		retryCmd := comm.Cmd{
-			Command: command,
-			Dir:     k.ConfigDirectory,
-			Stdout:  cmd.Stdout,
-			Stderr:  cmd.Stderr,
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("cmd %s was cancelled: %w", command, ctx.Err())
 		}
-		err = retryCmd.RetryCommandWithCallback(maxRetryCount, func() error {
-			l, errParse := collectErrors(output)
-			if errParse != nil {
-				output.Reset()
-				log.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
-				return nil
-			}
-			if len(l) > 0 {
-				log.Error().Msgf("failed to execute cmd: %s: %s", retryCmd.Command, l.prettyPrint())
-			}
-			output.Reset()
-			return nil
-		})
 
-		if err != nil {
-			l, errParse := collectErrors(output)
-			if errParse != nil {
-				log.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
-				return fmt.Errorf("failed to execute cmd: %s: %w", retryCmd.Command, err)
-			}
-			if len(l) > 0 {
-				err = fmt.Errorf("%w: %s", err, l.prettyPrint())
-			}
-			return fmt.Errorf("failed to execute cmd: %s: %w", retryCmd.Command, err)
+		logger.Warn().Msgf("Retrying command %s... (%d/%d)", command, retry, maxRetryCount)
+		err = run()
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("cmd %s was cancelled: %w", command, ctx.Err())
 		}
+
+		l, errParse := collectErrors(output)
+		if errParse != nil {
+			logger.Warn().Msgf("failed to parse errors from kubeone logs: %v", errParse)
+			return fmt.Errorf("failed to execute cmd: %s: %w", command, err)
+		}
+		if len(l) > 0 {
+			err = fmt.Errorf("%w: %s", err, l.prettyPrint())
+		}
+		return fmt.Errorf("failed to execute cmd: %s: %w", command, err)
+	}
+	return nil
+}
+
+// retryBackoff returns the time to wait before the retry, 5 * (2 ^ retry) seconds capped at maxRetryBackoff,
+// the same as the retries of the commands.
+func retryBackoff(retry int) time.Duration {
+	return min(5*time.Second<<retry, maxRetryBackoff)
+}
+
+// ValidateConfig will run `kubeone config dump -m kubeone.yaml` in the ConfigDirectory, which
+// fails if the manifest does not pass the kubeone validation. Does not access the nodes.
+func (k *Kubeone) ValidateConfig() error {
+	cmd := exec.Command("kubeone", "config", "dump", "-m", "kubeone.yaml")
+	cmd.Dir = k.ConfigDirectory
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("invalid kubeone manifest: %w: %s", err, out)
 	}
 	return nil
 }
 
+// VersionInfo is the version of the kubeone binary as reported by `kubeone version`.
+type VersionInfo struct {
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	GitVersion string `json:"gitVersion"`
+}
+
+// Version will run `kubeone version` and return the version of the kubeone binary.
+func Version() (*VersionInfo, error) {
+	out, err := exec.Command("kubeone", "version").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute kubeone version: %w", err)
+	}
+
+	var version struct {
+		Kubeone VersionInfo `json:"kubeone"`
+	}
+	if err := json.Unmarshal(out, &version); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeone version: %w", err)
+	}
+
+	return &version.Kubeone, nil
+}
+
+// applyFlags returns the optional flags passed to kubeone apply.
+func (k *Kubeone) applyFlags() string {
+	var flags []string
+	if k.Reconcile {
+		flags = append(flags, "--create-machine-deployments=false")
+	}
+	return strings.Join(flags, " ")
+}
+
+// debugLogging reports whether debug logs are emitted, honoring both the level of the logger
+// and the global level, which may be changed at runtime.
+func debugLogging() bool {
+	return log.Logger.GetLevel() <= zerolog.DebugLevel && zerolog.GlobalLevel() <= zerolog.DebugLevel
+}
+
 func structuredLogging() string {
-	if log.Logger.GetLevel() == zerolog.DebugLevel {
+	if debugLogging() {
 		return ""
 	}
 	return "--log-format json"
@@ -0,0 +1,58 @@
+package kubeone
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyFlags(t *testing.T) {
+	tests := []struct {
+		Name    string
+		kubeone Kubeone
+		want    string
+	}{
+		{Name: "defaults", kubeone: Kubeone{}, want: ""},
+		{Name: "reconcile", kubeone: Kubeone{Reconcile: true}, want: "--create-machine-deployments=false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			if got := tt.kubeone.applyFlags(); got != tt.want {
+				t.Errorf("applyFlags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyContextCancelledBetweenRetries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	// Without a manifest, every attempt fails before the ctx is done, thus it is cancelled during the backoff.
+	k := Kubeone{ConfigDirectory: t.TempDir(), SpawnProcessLimit: make(chan struct{}, 1)}
+	start := time.Now()
+	err := k.ApplyContext(ctx, "test")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ApplyContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed >= retryBackoff(1) {
+		t.Errorf("ApplyContext() returned after %s, want before the first retry", elapsed)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{retry: 1, want: 10 * time.Second},
+		{retry: 2, want: 20 * time.Second},
+		{retry: 6, want: 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := retryBackoff(tt.retry); got != tt.want {
+			t.Errorf("retryBackoff(%d) = %s, want %s", tt.retry, got, tt.want)
+		}
+	}
+}
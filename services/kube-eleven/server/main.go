@@ -10,15 +10,18 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/berops/claudie/internal/utils"
 	"github.com/berops/claudie/services/kube-eleven/server/adapters/inbound/grpc"
 	"github.com/berops/claudie/services/kube-eleven/server/domain/usecases"
+	kube_eleven "github.com/berops/claudie/services/kube-eleven/server/domain/utils/kube-eleven"
 )
 
 const (
@@ -29,6 +32,16 @@ func main() {
 	// Initialize logger
 	utils.InitLog("kube-eleven")
 
+	// Directories left behind by a crashed build of this replica may contain SSH keys.
+	removeOrphaned, _ := strconv.ParseBool(utils.GetEnvDefault("KUBE_ELEVEN_REMOVE_ORPHANED_BUILDS", "false"))
+	orphaned, err := kube_eleven.CleanupOrphanedBuilds(removeOrphaned)
+	if err != nil {
+		log.Err(err).Msgf("Failed to clean up orphaned build directories")
+	}
+	for _, build := range orphaned {
+		log.Warn().Str("cluster", build.ClusterID).Bool("removed", removeOrphaned).Msgf("Found orphaned build directory %s", build.Directory)
+	}
+
 	usecases := &usecases.Usecases{
 		SpawnProcessLimit: make(chan struct{}, usecases.SpawnProcessLimit),
 	}
@@ -76,6 +89,28 @@ func main() {
 		return err
 	})
 
+	// Change the global log level at runtime, SIGUSR1 switches to the debug level and SIGUSR2 to the info level.
+	// The level of the logger stays in effect, thus the debug logs are emitted only if enabled by the GOLANG_LOG.
+	errGroup.Go(func() error {
+		logLevelSignalChan := make(chan os.Signal, 1)
+		signal.Notify(logLevelSignalChan, syscall.SIGUSR1, syscall.SIGUSR2)
+		defer signal.Stop(logLevelSignalChan)
+
+		for {
+			select {
+			case <-errGroupContext.Done():
+				return nil
+			case sig := <-logLevelSignalChan:
+				level := zerolog.InfoLevel
+				if sig == syscall.SIGUSR1 {
+					level = zerolog.DebugLevel
+				}
+				zerolog.SetGlobalLevel(level)
+				log.Info().Msgf("Received signal %v, using log with the level %q", sig, level)
+			}
+		}
+	})
+
 	errGroup.Go(func() error {
 		http.Handle("/metrics", promhttp.Handler())
 		return metricsServer.ListenAndServe()
@@ -1,4 +1,4 @@
-apiVersion: kubeone.k8c.io/v1beta2
+apiVersion: {{ .APIVersion }}
 kind: KubeOneCluster
 name: '{{ .ClusterName }}'
 
@@ -22,6 +22,12 @@ cloudProvider:
 apiEndpoint:
   host: '{{ .APIEndpoint }}'
   port: 6443
+{{- if .AlternativeNames }}
+  alternativeNames:
+  {{- range $name := .AlternativeNames }}
+  - '{{ $name }}'
+  {{- end }}
+{{- end }}
 
 {{- $privateKey := "./private.pem" }}
 controlPlane:
@@ -29,6 +29,9 @@ type Deleter struct {
 	workerNodes   []string
 	cluster       *pb.K8Scluster
 	clusterPrefix string
+	drain         DrainOptions
+	// drained is the number of nodes drained so far.
+	drained int
 
 	logger zerolog.Logger
 }
@@ -52,6 +55,7 @@ func NewDeleter(masterNodes, workerNodes []string, cluster *pb.K8Scluster) *Dele
 		workerNodes:   workerNodes,
 		cluster:       cluster,
 		clusterPrefix: prefix,
+		drain:         drainOptionsFromEnv(),
 
 		logger: utils.CreateLoggerWithClusterName(prefix),
 	}
@@ -125,8 +129,13 @@ func (d *Deleter) DeleteNodes() (*pb.K8Scluster, error) {
 func (d *Deleter) deleteNodesByName(kc kubectl.Kubectl, nodeName string, realNodeNames []string) error {
 	if realNodeName := utils.FindName(realNodeNames, nodeName); realNodeName != "" {
 		d.logger.Info().Msgf("Deleting node %s from k8s cluster", realNodeName)
+		// Let the scheduler place the pods evicted from the previously drained node first.
+		if d.drained > 0 {
+			time.Sleep(d.drain.Interval)
+		}
+		d.drained++
 		//kubectl drain <node-name> --ignore-daemonsets --delete-emptydir-data
-		err := kc.KubectlDrain(realNodeName)
+		err := kc.KubectlDrain(realNodeName, d.drain.kubectlOptions()...)
 		if err != nil {
 			return fmt.Errorf("error while draining node %s from cluster %s : %w", nodeName, d.clusterPrefix, err)
 		}
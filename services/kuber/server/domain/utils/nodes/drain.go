@@ -0,0 +1,54 @@
+package nodes
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/berops/claudie/internal/utils"
+)
+
+// DrainOptions configures the draining of the nodes before they are deleted.
+// The zero value drains the nodes with the kubectl defaults.
+type DrainOptions struct {
+	// Interval is the pause after draining a node, before the next node is drained,
+	// giving the scheduler time to place the evicted pods.
+	Interval time.Duration
+	// DisableEviction deletes the pods instead of evicting them, bypassing the PodDisruptionBudgets.
+	DisableEviction bool
+}
+
+// drainOptionsFromEnv reads the DrainOptions from the KUBER_DRAIN_INTERVAL and KUBER_DRAIN_DISABLE_EVICTION
+// env variables. Invalid values are logged and the defaults are used instead.
+func drainOptionsFromEnv() DrainOptions {
+	var opts DrainOptions
+
+	if v := utils.GetEnvDefault("KUBER_DRAIN_INTERVAL", ""); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval < 0 {
+			log.Warn().Msgf("Ignoring invalid KUBER_DRAIN_INTERVAL %q", v)
+		} else {
+			opts.Interval = interval
+		}
+	}
+
+	if v := utils.GetEnvDefault("KUBER_DRAIN_DISABLE_EVICTION", ""); v != "" {
+		disable, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Warn().Msgf("Ignoring invalid KUBER_DRAIN_DISABLE_EVICTION %q", v)
+		} else {
+			opts.DisableEviction = disable
+		}
+	}
+
+	return opts
+}
+
+// kubectlOptions returns the kubectl drain flags for the options.
+func (o DrainOptions) kubectlOptions() []string {
+	if o.DisableEviction {
+		return []string{"--disable-eviction"}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package nodes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainOptionsFromEnv(t *testing.T) {
+	tests := []struct {
+		Name            string
+		interval        string
+		disableEviction string
+		want            DrainOptions
+		wantFlags       []string
+	}{
+		{
+			Name: "defaults",
+			want: DrainOptions{},
+		},
+		{
+			Name:            "configured",
+			interval:        "30s",
+			disableEviction: "true",
+			want:            DrainOptions{Interval: 30 * time.Second, DisableEviction: true},
+			wantFlags:       []string{"--disable-eviction"},
+		},
+		{
+			Name:            "eviction-enabled",
+			interval:        "1m",
+			disableEviction: "false",
+			want:            DrainOptions{Interval: time.Minute},
+		},
+		{
+			Name:            "invalid",
+			interval:        "30",
+			disableEviction: "yes",
+			want:            DrainOptions{},
+		},
+		{
+			Name:     "negative-interval",
+			interval: "-1s",
+			want:     DrainOptions{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			t.Setenv("KUBER_DRAIN_INTERVAL", tt.interval)
+			t.Setenv("KUBER_DRAIN_DISABLE_EVICTION", tt.disableEviction)
+
+			got := drainOptionsFromEnv()
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantFlags, got.kubectlOptions())
+		})
+	}
+}
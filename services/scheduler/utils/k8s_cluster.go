@@ -21,7 +21,7 @@ func CreateK8sCluster(unmarshalledManifest *manifest.Manifest) ([]*pb.K8Scluster
 				Name: strings.ToLower(cluster.Name),
 				Hash: utils.CreateHash(utils.HashLength),
 			},
-			Kubernetes: cluster.Version,
+			Kubernetes: cluster.KubernetesVersion(),
 			Network:    cluster.Network,
 		}
 